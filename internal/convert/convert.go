@@ -0,0 +1,13 @@
+package convert
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// StringOrNull maps the empty string the API uses for absent fields to null.
+func StringOrNull(s string) types.String {
+	if s == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(s)
+}
@@ -0,0 +1,183 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+
+	"github.com/shadeform/terraform-provider-shadeform/internal/datasources/instances"
+	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+)
+
+var (
+	_ datasource.DataSource                   = &InstanceDataSource{}
+	_ datasource.DataSourceWithConfigure      = &InstanceDataSource{}
+	_ datasource.DataSourceWithValidateConfig = &InstanceDataSource{}
+)
+
+type InstanceDataSource struct {
+	client *provider_shadeform.Client
+}
+
+// InstanceDataSourceModel shares its attributes with the entries of the
+// shadeform_instances data source.
+type InstanceDataSourceModel = instances.InstanceModel
+
+func NewInstanceDataSource() datasource.DataSource {
+	return &InstanceDataSource{}
+}
+
+// Metadata returns the data source type name.
+func (d *InstanceDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_instance"
+}
+
+// Schema defines the schema for the data source.
+func (d *InstanceDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attributes := instances.InstanceAttributes()
+	attributes["id"] = schema.StringAttribute{
+		Description: "The unique identifier of the instance to look up. Exactly one of id or name must be set.",
+		Optional:    true,
+		Computed:    true,
+	}
+	attributes["name"] = schema.StringAttribute{
+		Description: "The exact name of the instance to look up. Exactly one of id or name must be set.",
+		Optional:    true,
+		Computed:    true,
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Retrieve a single Shadeform instance by ID or name.",
+		Attributes:  attributes,
+	}
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *InstanceDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*provider_shadeform.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *provider_shadeform.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+// ValidateConfig checks that exactly one of id or name is set.
+func (d *InstanceDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var data InstanceDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Values that are not known yet can't be checked until apply
+	if data.Id.IsUnknown() || data.Name.IsUnknown() {
+		return
+	}
+
+	if data.Id.IsNull() == data.Name.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("id"),
+			"Invalid Instance Lookup",
+			"Exactly one of id or name must be set to look up an instance.",
+		)
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *InstanceDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data InstanceDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var instance *provider_shadeform.Instance
+	if !data.Id.IsNull() {
+		result, err := d.client.GetInstance(ctx, data.Id.ValueString())
+		if provider_shadeform.IsNotFound(err) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("id"),
+				"Instance not found",
+				fmt.Sprintf("No instance with ID %s exists.", data.Id.ValueString()),
+			)
+			return
+		}
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error reading instance",
+				"Could not read instance, unexpected error: "+err.Error(),
+			)
+			return
+		}
+		if result.ID == "" {
+			result.ID = data.Id.ValueString()
+		}
+		instance = result
+	} else {
+		result, err := d.client.ListInstances(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error reading instances",
+				"Could not read instances, unexpected error: "+err.Error(),
+			)
+			return
+		}
+
+		name := data.Name.ValueString()
+		var matches []*provider_shadeform.Instance
+		for i := range result {
+			// Deleted instances keep their name, so skip them to allow reuse
+			if result[i].Name == name && result[i].Status != provider_shadeform.InstanceStatusDeleted {
+				matches = append(matches, &result[i])
+			}
+		}
+
+		switch len(matches) {
+		case 0:
+			resp.Diagnostics.AddAttributeError(
+				path.Root("name"),
+				"Instance not found",
+				fmt.Sprintf("No instance named %q exists.", name),
+			)
+			return
+		case 1:
+			instance = matches[0]
+		default:
+			ids := make([]string, 0, len(matches))
+			for _, m := range matches {
+				ids = append(ids, m.ID)
+			}
+			resp.Diagnostics.AddAttributeError(
+				path.Root("name"),
+				"Ambiguous instance name",
+				fmt.Sprintf("Found %d instances named %q (%s). Look the instance up by id instead.", len(matches), name, strings.Join(ids, ", ")),
+			)
+			return
+		}
+	}
+
+	data = instances.NewInstanceModel(instance)
+
+	// Set state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -54,6 +54,29 @@ type BootTimeModel struct {
 	MaxBootInSec types.Int64 `tfsdk:"max_boot_in_sec"`
 }
 
+var availabilityAttrTypes = map[string]attr.Type{
+	"region":       types.StringType,
+	"available":    types.BoolType,
+	"display_name": types.StringType,
+}
+
+var bootTimeAttrTypes = map[string]attr.Type{
+	"min_boot_in_sec": types.Int64Type,
+	"max_boot_in_sec": types.Int64Type,
+}
+
+var instanceTypeAttrTypes = map[string]attr.Type{
+	"cloud":               types.StringType,
+	"region":              types.StringType,
+	"shade_instance_type": types.StringType,
+	"cloud_instance_type": types.StringType,
+	"hourly_price":        types.Int64Type,
+	"deployment_type":     types.StringType,
+	"os_options":          types.ListType{ElemType: types.StringType},
+	"availability":        types.ListType{ElemType: types.ObjectType{AttrTypes: availabilityAttrTypes}},
+	"boot_time":           types.ObjectType{AttrTypes: bootTimeAttrTypes},
+}
+
 func NewInstanceTypesDataSource() datasource.DataSource {
 	return &InstanceTypesDataSource{}
 }
@@ -99,30 +122,7 @@ func (d *InstanceTypesDataSource) Schema(_ context.Context, _ datasource.SchemaR
 			"instance_types": schema.ListAttribute{
 				Description: "List of available instance types.",
 				Computed:    true,
-				ElementType: types.ObjectType{
-					AttrTypes: map[string]attr.Type{
-						"cloud":               types.StringType,
-						"region":              types.StringType,
-						"shade_instance_type": types.StringType,
-						"cloud_instance_type": types.StringType,
-						"hourly_price":        types.Int64Type,
-						"deployment_type":     types.StringType,
-						"os_options":          types.ListType{ElemType: types.StringType},
-						"availability": types.ListType{ElemType: types.ObjectType{
-							AttrTypes: map[string]attr.Type{
-								"region":       types.StringType,
-								"available":    types.BoolType,
-								"display_name": types.StringType,
-							},
-						}},
-						"boot_time": types.ObjectType{
-							AttrTypes: map[string]attr.Type{
-								"min_boot_in_sec": types.Int64Type,
-								"max_boot_in_sec": types.Int64Type,
-							},
-						},
-					},
-				},
+				ElementType: types.ObjectType{AttrTypes: instanceTypeAttrTypes},
 			},
 		},
 	}
@@ -184,7 +184,7 @@ func (d *InstanceTypesDataSource) Read(ctx context.Context, req datasource.ReadR
 	}
 
 	// Get instance types from API
-	result, err := d.client.GetInstanceTypes(params)
+	result, err := d.client.GetInstanceTypes(ctx, params)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error reading instance types",
@@ -193,215 +193,81 @@ func (d *InstanceTypesDataSource) Read(ctx context.Context, req datasource.ReadR
 		return
 	}
 
-	// Parse the response
-	instanceTypesRaw, ok := result["instance_types"]
-	if !ok {
-		resp.Diagnostics.AddError(
-			"Error reading instance types",
-			"Response does not contain instance_types field",
-		)
-		return
-	}
-
-	instanceTypesArray, ok := instanceTypesRaw.([]interface{})
-	if !ok {
-		resp.Diagnostics.AddError(
-			"Error reading instance types",
-			"instance_types field is not an array",
-		)
-		return
-	}
-
 	// Convert to Terraform types
-	var instanceTypes []attr.Value
-	for _, instanceTypeRaw := range instanceTypesArray {
-		instanceTypeMap, ok := instanceTypeRaw.(map[string]interface{})
-		if !ok {
-			continue
-		}
+	instanceTypes := make([]attr.Value, 0, len(result))
+	for _, it := range result {
+		instanceTypes = append(instanceTypes, flattenInstanceType(it))
+	}
 
-		instanceType := InstanceTypeModel{}
+	// Set the instance types
+	data.InstanceTypes = types.ListValueMust(types.ObjectType{AttrTypes: instanceTypeAttrTypes}, instanceTypes)
 
-		// Parse basic fields
-		if cloud, ok := instanceTypeMap["cloud"].(string); ok {
-			instanceType.Cloud = types.StringValue(cloud)
-		}
-		if region, ok := instanceTypeMap["region"].(string); ok {
-			instanceType.Region = types.StringValue(region)
-		}
-		if shadeInstanceType, ok := instanceTypeMap["shade_instance_type"].(string); ok {
-			instanceType.ShadeInstanceType = types.StringValue(shadeInstanceType)
-		}
-		if cloudInstanceType, ok := instanceTypeMap["cloud_instance_type"].(string); ok {
-			instanceType.CloudInstanceType = types.StringValue(cloudInstanceType)
-		}
-		if hourlyPrice, ok := instanceTypeMap["hourly_price"].(float64); ok {
-			instanceType.HourlyPrice = types.Int64Value(int64(hourlyPrice))
-		}
-		if deploymentType, ok := instanceTypeMap["deployment_type"].(string); ok {
-			instanceType.DeploymentType = types.StringValue(deploymentType)
-		}
+	// Set state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
 
-		// Parse OS options
-		if config, ok := instanceTypeMap["configuration"].(map[string]interface{}); ok {
-			if osOptionsRaw, ok := config["os_options"].([]interface{}); ok {
-				var osOptions []attr.Value
-				for _, osOption := range osOptionsRaw {
-					if osOptionStr, ok := osOption.(string); ok {
-						osOptions = append(osOptions, types.StringValue(osOptionStr))
-					}
-				}
-				if len(osOptions) > 0 {
-					instanceType.OsOptions = types.ListValueMust(types.StringType, osOptions)
-				} else {
-					instanceType.OsOptions = types.ListNull(types.StringType)
-				}
-			}
-		}
+// flattenInstanceType converts an API instance type into its Terraform object.
+func flattenInstanceType(it provider_shadeform.InstanceType) attr.Value {
+	instanceType := InstanceTypeModel{
+		Cloud:             types.StringValue(it.Cloud),
+		Region:            types.StringValue(it.Region),
+		ShadeInstanceType: types.StringValue(it.ShadeInstanceType),
+		CloudInstanceType: types.StringValue(it.CloudInstanceType),
+		HourlyPrice:       types.Int64Value(int64(it.HourlyPrice)),
+		DeploymentType:    types.StringValue(it.DeploymentType),
+		OsOptions:         types.ListNull(types.StringType),
+		Availability:      types.ListNull(types.ObjectType{AttrTypes: availabilityAttrTypes}),
+		BootTime:          types.ObjectNull(bootTimeAttrTypes),
+	}
 
-		// Parse availability
-		if availabilityRaw, ok := instanceTypeMap["availability"].([]interface{}); ok {
-			var availability []attr.Value
-			for _, availRaw := range availabilityRaw {
-				if availMap, ok := availRaw.(map[string]interface{}); ok {
-					avail := AvailabilityModel{}
-					if region, ok := availMap["region"].(string); ok {
-						avail.Region = types.StringValue(region)
-					}
-					if available, ok := availMap["available"].(bool); ok {
-						avail.Available = types.BoolValue(available)
-					}
-					if displayName, ok := availMap["display_name"].(string); ok {
-						avail.DisplayName = types.StringValue(displayName)
-					}
-					availability = append(availability, types.ObjectValueMust(
-						map[string]attr.Type{
-							"region":       types.StringType,
-							"available":    types.BoolType,
-							"display_name": types.StringType,
-						},
-						map[string]attr.Value{
-							"region":       avail.Region,
-							"available":    avail.Available,
-							"display_name": avail.DisplayName,
-						},
-					))
-				}
-			}
-			if len(availability) > 0 {
-				instanceType.Availability = types.ListValueMust(
-					types.ObjectType{
-						AttrTypes: map[string]attr.Type{
-							"region":       types.StringType,
-							"available":    types.BoolType,
-							"display_name": types.StringType,
-						},
-					},
-					availability,
-				)
-			} else {
-				instanceType.Availability = types.ListNull(
-					types.ObjectType{
-						AttrTypes: map[string]attr.Type{
-							"region":       types.StringType,
-							"available":    types.BoolType,
-							"display_name": types.StringType,
-						},
-					},
-				)
-			}
+	// Parse OS options
+	if len(it.Configuration.OsOptions) > 0 {
+		osOptions := make([]attr.Value, 0, len(it.Configuration.OsOptions))
+		for _, osOption := range it.Configuration.OsOptions {
+			osOptions = append(osOptions, types.StringValue(osOption))
 		}
+		instanceType.OsOptions = types.ListValueMust(types.StringType, osOptions)
+	}
 
-		// Parse boot time
-		if bootTimeRaw, ok := instanceTypeMap["boot_time"].(map[string]interface{}); ok {
-			bootTime := BootTimeModel{}
-			if minBoot, ok := bootTimeRaw["min_boot_in_sec"].(float64); ok {
-				bootTime.MinBootInSec = types.Int64Value(int64(minBoot))
-			}
-			if maxBoot, ok := bootTimeRaw["max_boot_in_sec"].(float64); ok {
-				bootTime.MaxBootInSec = types.Int64Value(int64(maxBoot))
-			}
-			instanceType.BootTime = types.ObjectValueMust(
-				map[string]attr.Type{
-					"min_boot_in_sec": types.Int64Type,
-					"max_boot_in_sec": types.Int64Type,
-				},
+	// Parse availability
+	if len(it.Availability) > 0 {
+		availability := make([]attr.Value, 0, len(it.Availability))
+		for _, avail := range it.Availability {
+			availability = append(availability, types.ObjectValueMust(
+				availabilityAttrTypes,
 				map[string]attr.Value{
-					"min_boot_in_sec": bootTime.MinBootInSec,
-					"max_boot_in_sec": bootTime.MaxBootInSec,
+					"region":       types.StringValue(avail.Region),
+					"available":    types.BoolValue(avail.Available),
+					"display_name": types.StringValue(avail.DisplayName),
 				},
-			)
+			))
 		}
+		instanceType.Availability = types.ListValueMust(types.ObjectType{AttrTypes: availabilityAttrTypes}, availability)
+	}
 
-		// Convert to ObjectValue
-		instanceTypeObj := types.ObjectValueMust(
-			map[string]attr.Type{
-				"cloud":               types.StringType,
-				"region":              types.StringType,
-				"shade_instance_type": types.StringType,
-				"cloud_instance_type": types.StringType,
-				"hourly_price":        types.Int64Type,
-				"deployment_type":     types.StringType,
-				"os_options":          types.ListType{ElemType: types.StringType},
-				"availability": types.ListType{ElemType: types.ObjectType{
-					AttrTypes: map[string]attr.Type{
-						"region":       types.StringType,
-						"available":    types.BoolType,
-						"display_name": types.StringType,
-					},
-				}},
-				"boot_time": types.ObjectType{
-					AttrTypes: map[string]attr.Type{
-						"min_boot_in_sec": types.Int64Type,
-						"max_boot_in_sec": types.Int64Type,
-					},
-				},
-			},
+	// Parse boot time
+	if it.BootTime != nil {
+		instanceType.BootTime = types.ObjectValueMust(
+			bootTimeAttrTypes,
 			map[string]attr.Value{
-				"cloud":               instanceType.Cloud,
-				"region":              instanceType.Region,
-				"shade_instance_type": instanceType.ShadeInstanceType,
-				"cloud_instance_type": instanceType.CloudInstanceType,
-				"hourly_price":        instanceType.HourlyPrice,
-				"deployment_type":     instanceType.DeploymentType,
-				"os_options":          instanceType.OsOptions,
-				"availability":        instanceType.Availability,
-				"boot_time":           instanceType.BootTime,
+				"min_boot_in_sec": types.Int64Value(it.BootTime.MinBootInSec),
+				"max_boot_in_sec": types.Int64Value(it.BootTime.MaxBootInSec),
 			},
 		)
-
-		instanceTypes = append(instanceTypes, instanceTypeObj)
 	}
 
-	// Set the instance types
-	data.InstanceTypes = types.ListValueMust(
-		types.ObjectType{
-			AttrTypes: map[string]attr.Type{
-				"cloud":               types.StringType,
-				"region":              types.StringType,
-				"shade_instance_type": types.StringType,
-				"cloud_instance_type": types.StringType,
-				"hourly_price":        types.Int64Type,
-				"deployment_type":     types.StringType,
-				"os_options":          types.ListType{ElemType: types.StringType},
-				"availability": types.ListType{ElemType: types.ObjectType{
-					AttrTypes: map[string]attr.Type{
-						"region":       types.StringType,
-						"available":    types.BoolType,
-						"display_name": types.StringType,
-					},
-				}},
-				"boot_time": types.ObjectType{
-					AttrTypes: map[string]attr.Type{
-						"min_boot_in_sec": types.Int64Type,
-						"max_boot_in_sec": types.Int64Type,
-					},
-				},
-			},
+	return types.ObjectValueMust(
+		instanceTypeAttrTypes,
+		map[string]attr.Value{
+			"cloud":               instanceType.Cloud,
+			"region":              instanceType.Region,
+			"shade_instance_type": instanceType.ShadeInstanceType,
+			"cloud_instance_type": instanceType.CloudInstanceType,
+			"hourly_price":        instanceType.HourlyPrice,
+			"deployment_type":     instanceType.DeploymentType,
+			"os_options":          instanceType.OsOptions,
+			"availability":        instanceType.Availability,
+			"boot_time":           instanceType.BootTime,
 		},
-		instanceTypes,
 	)
-
-	// Set state
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
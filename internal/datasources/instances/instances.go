@@ -0,0 +1,429 @@
+package instances
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/shadeform/terraform-provider-shadeform/internal/convert"
+	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+)
+
+var (
+	_ datasource.DataSource                   = &InstancesDataSource{}
+	_ datasource.DataSourceWithConfigure      = &InstancesDataSource{}
+	_ datasource.DataSourceWithValidateConfig = &InstancesDataSource{}
+)
+
+type InstancesDataSource struct {
+	client *provider_shadeform.Client
+}
+
+type InstancesDataSourceModel struct {
+	Status            types.String `tfsdk:"status"`
+	Cloud             types.String `tfsdk:"cloud"`
+	Region            types.String `tfsdk:"region"`
+	ShadeInstanceType types.String `tfsdk:"shade_instance_type"`
+	NameRegex         types.String `tfsdk:"name_regex"`
+	Tags              types.Set    `tfsdk:"tags"`
+	Instances         types.List   `tfsdk:"instances"`
+}
+
+// InstanceModel is the read-only view of an instance shared by the instance
+// data sources.
+type InstanceModel struct {
+	Id                types.String `tfsdk:"id"`
+	Cloud             types.String `tfsdk:"cloud"`
+	Region            types.String `tfsdk:"region"`
+	ShadeInstanceType types.String `tfsdk:"shade_instance_type"`
+	ShadeCloud        types.Bool   `tfsdk:"shade_cloud"`
+	Name              types.String `tfsdk:"name"`
+	Os                types.String `tfsdk:"os"`
+	SshKeyId          types.String `tfsdk:"ssh_key_id"`
+	TemplateId        types.String `tfsdk:"template_id"`
+	VolumeIds         types.List   `tfsdk:"volume_ids"`
+	CloudInstanceType types.String `tfsdk:"cloud_instance_type"`
+	CloudAssignedID   types.String `tfsdk:"cloud_assigned_id"`
+	IP                types.String `tfsdk:"ip"`
+	SshUser           types.String `tfsdk:"ssh_user"`
+	SshPort           types.Int64  `tfsdk:"ssh_port"`
+	Status            types.String `tfsdk:"status"`
+	CostEstimate      types.String `tfsdk:"cost_estimate"`
+	HourlyPrice       types.String `tfsdk:"hourly_price"`
+	CreatedAt         types.String `tfsdk:"created_at"`
+	Tags              types.Set    `tfsdk:"tags"`
+}
+
+// InstanceAttrTypes are the attribute types of InstanceModel.
+var InstanceAttrTypes = map[string]attr.Type{
+	"id":                  types.StringType,
+	"cloud":               types.StringType,
+	"region":              types.StringType,
+	"shade_instance_type": types.StringType,
+	"shade_cloud":         types.BoolType,
+	"name":                types.StringType,
+	"os":                  types.StringType,
+	"ssh_key_id":          types.StringType,
+	"template_id":         types.StringType,
+	"volume_ids":          types.ListType{ElemType: types.StringType},
+	"cloud_instance_type": types.StringType,
+	"cloud_assigned_id":   types.StringType,
+	"ip":                  types.StringType,
+	"ssh_user":            types.StringType,
+	"ssh_port":            types.Int64Type,
+	"status":              types.StringType,
+	"cost_estimate":       types.StringType,
+	"hourly_price":        types.StringType,
+	"created_at":          types.StringType,
+	"tags":                types.SetType{ElemType: types.StringType},
+}
+
+// InstanceAttributes returns the computed schema attributes of InstanceModel.
+func InstanceAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			Description: "The unique identifier for the instance.",
+			Computed:    true,
+		},
+		"cloud": schema.StringAttribute{
+			Description: "The cloud provider.",
+			Computed:    true,
+		},
+		"region": schema.StringAttribute{
+			Description: "The region where the instance is deployed.",
+			Computed:    true,
+		},
+		"shade_instance_type": schema.StringAttribute{
+			Description: "The Shadeform standardized instance type.",
+			Computed:    true,
+		},
+		"shade_cloud": schema.BoolAttribute{
+			Description: "Whether the instance runs on Shade Cloud or a linked cloud account.",
+			Computed:    true,
+		},
+		"name": schema.StringAttribute{
+			Description: "The name of the instance.",
+			Computed:    true,
+		},
+		"os": schema.StringAttribute{
+			Description: "The operating system of the instance.",
+			Computed:    true,
+		},
+		"ssh_key_id": schema.StringAttribute{
+			Description: "The ID of the SSH key used for this instance.",
+			Computed:    true,
+		},
+		"template_id": schema.StringAttribute{
+			Description: "The ID of the template used for this instance.",
+			Computed:    true,
+		},
+		"volume_ids": schema.ListAttribute{
+			ElementType: types.StringType,
+			Description: "List of volume IDs mounted to the instance.",
+			Computed:    true,
+		},
+		"cloud_instance_type": schema.StringAttribute{
+			Description: "The type of the instance in the cloud provider.",
+			Computed:    true,
+		},
+		"cloud_assigned_id": schema.StringAttribute{
+			Description: "The ID of the instance in the cloud provider.",
+			Computed:    true,
+		},
+		"ip": schema.StringAttribute{
+			Description: "The IP address of the instance.",
+			Computed:    true,
+		},
+		"ssh_user": schema.StringAttribute{
+			Description: "The user to use for SSH access to the instance.",
+			Computed:    true,
+		},
+		"ssh_port": schema.Int64Attribute{
+			Description: "The port to use for SSH access to the instance.",
+			Computed:    true,
+		},
+		"status": schema.StringAttribute{
+			Description: "The status of the instance.",
+			Computed:    true,
+		},
+		"cost_estimate": schema.StringAttribute{
+			Description: "The cost estimate so far for the instance.",
+			Computed:    true,
+		},
+		"hourly_price": schema.StringAttribute{
+			Description: "The hourly price of the instance.",
+			Computed:    true,
+		},
+		"created_at": schema.StringAttribute{
+			Description: "The date and time the instance was created.",
+			Computed:    true,
+		},
+		"tags": schema.SetAttribute{
+			ElementType: types.StringType,
+			Description: "The tags attached to the instance.",
+			Computed:    true,
+		},
+	}
+}
+
+// NewInstanceModel converts an API instance into an InstanceModel.
+func NewInstanceModel(instance *provider_shadeform.Instance) InstanceModel {
+	m := InstanceModel{
+		Id:                types.StringValue(instance.ID),
+		Cloud:             types.StringValue(instance.Cloud),
+		Region:            types.StringValue(instance.Region),
+		ShadeInstanceType: types.StringValue(instance.ShadeInstanceType),
+		ShadeCloud:        types.BoolPointerValue(instance.ShadeCloud),
+		Name:              types.StringValue(instance.Name),
+		Os:                convert.StringOrNull(instance.Os),
+		SshKeyId:          convert.StringOrNull(instance.SshKeyID),
+		TemplateId:        convert.StringOrNull(instance.TemplateID),
+		VolumeIds:         types.ListNull(types.StringType),
+		CloudInstanceType: convert.StringOrNull(instance.CloudInstanceType),
+		CloudAssignedID:   convert.StringOrNull(instance.CloudAssignedID),
+		IP:                convert.StringOrNull(instance.IP),
+		SshUser:           convert.StringOrNull(instance.SshUser),
+		SshPort:           types.Int64Null(),
+		Status:            convert.StringOrNull(instance.Status),
+		CostEstimate:      convert.StringOrNull(string(instance.CostEstimate)),
+		HourlyPrice:       convert.StringOrNull(string(instance.HourlyPrice)),
+		CreatedAt:         convert.StringOrNull(instance.CreatedAt),
+		Tags:              types.SetNull(types.StringType),
+	}
+
+	if instance.SshPort != 0 {
+		m.SshPort = types.Int64Value(instance.SshPort)
+	}
+
+	if len(instance.VolumeIDs) > 0 {
+		volumeIds := make([]attr.Value, 0, len(instance.VolumeIDs))
+		for _, v := range instance.VolumeIDs {
+			volumeIds = append(volumeIds, types.StringValue(v))
+		}
+		m.VolumeIds = types.ListValueMust(types.StringType, volumeIds)
+	}
+
+	if len(instance.Tags) > 0 {
+		tags := make([]attr.Value, 0, len(instance.Tags))
+		for _, t := range instance.Tags {
+			tags = append(tags, types.StringValue(t))
+		}
+		m.Tags = types.SetValueMust(types.StringType, tags)
+	}
+
+	return m
+}
+
+func NewInstancesDataSource() datasource.DataSource {
+	return &InstancesDataSource{}
+}
+
+// Metadata returns the data source type name.
+func (d *InstancesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_instances"
+}
+
+// Schema defines the schema for the data source.
+func (d *InstancesDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Retrieve the instances in your Shadeform account.",
+		Attributes: map[string]schema.Attribute{
+			"status": schema.StringAttribute{
+				Description: "Filter the instance results by status.",
+				Optional:    true,
+			},
+			"cloud": schema.StringAttribute{
+				Description: "Filter the instance results by cloud.",
+				Optional:    true,
+			},
+			"region": schema.StringAttribute{
+				Description: "Filter the instance results by region.",
+				Optional:    true,
+			},
+			"shade_instance_type": schema.StringAttribute{
+				Description: "Filter the instance results by the shade instance type.",
+				Optional:    true,
+			},
+			"name_regex": schema.StringAttribute{
+				Description: "Filter the instance results by a regular expression matched against the instance name.",
+				Optional:    true,
+			},
+			"tags": schema.SetAttribute{
+				ElementType: types.StringType,
+				Description: "Filter the instance results to instances carrying all of these tags.",
+				Optional:    true,
+			},
+			"instances": schema.ListNestedAttribute{
+				Description: "List of matching instances.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: InstanceAttributes(),
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *InstancesDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*provider_shadeform.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *provider_shadeform.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+// ValidateConfig checks that name_regex compiles.
+func (d *InstancesDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var data InstancesDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !data.NameRegex.IsNull() && !data.NameRegex.IsUnknown() {
+		if _, err := regexp.Compile(data.NameRegex.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("name_regex"),
+				"Invalid Name Regex",
+				"name_regex is not a valid regular expression: "+err.Error(),
+			)
+		}
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *InstancesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data InstancesDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filter, diags := newInstanceFilter(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Get instances from API
+	result, err := d.client.ListInstances(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading instances",
+			"Could not read instances, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	// Convert matching instances to Terraform types
+	instances := make([]InstanceModel, 0, len(result))
+	for i := range result {
+		if filter.matches(&result[i]) {
+			instances = append(instances, NewInstanceModel(&result[i]))
+		}
+	}
+
+	data.Instances, diags = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: InstanceAttrTypes}, instances)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Set state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// instanceFilter holds the filters of the data source in plain Go types.
+type instanceFilter struct {
+	status            string
+	cloud             string
+	region            string
+	shadeInstanceType string
+	nameRegex         *regexp.Regexp
+	tags              []string
+}
+
+func newInstanceFilter(ctx context.Context, data InstancesDataSourceModel) (instanceFilter, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	filter := instanceFilter{
+		status:            data.Status.ValueString(),
+		cloud:             data.Cloud.ValueString(),
+		region:            data.Region.ValueString(),
+		shadeInstanceType: data.ShadeInstanceType.ValueString(),
+	}
+
+	if !data.NameRegex.IsNull() && !data.NameRegex.IsUnknown() {
+		nameRegex, err := regexp.Compile(data.NameRegex.ValueString())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("name_regex"),
+				"Invalid Name Regex",
+				"name_regex is not a valid regular expression: "+err.Error(),
+			)
+			return filter, diags
+		}
+		filter.nameRegex = nameRegex
+	}
+
+	if !data.Tags.IsNull() && !data.Tags.IsUnknown() {
+		diags.Append(data.Tags.ElementsAs(ctx, &filter.tags, false)...)
+	}
+
+	return filter, diags
+}
+
+// matches reports whether instance passes every filter that is set.
+func (f instanceFilter) matches(instance *provider_shadeform.Instance) bool {
+	if f.status != "" && instance.Status != f.status {
+		return false
+	}
+	if f.cloud != "" && instance.Cloud != f.cloud {
+		return false
+	}
+	if f.region != "" && instance.Region != f.region {
+		return false
+	}
+	if f.shadeInstanceType != "" && instance.ShadeInstanceType != f.shadeInstanceType {
+		return false
+	}
+	if f.nameRegex != nil && !f.nameRegex.MatchString(instance.Name) {
+		return false
+	}
+
+	tags := make(map[string]bool, len(instance.Tags))
+	for _, t := range instance.Tags {
+		tags[t] = true
+	}
+	for _, t := range f.tags {
+		if !tags[t] {
+			return false
+		}
+	}
+
+	return true
+}
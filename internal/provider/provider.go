@@ -2,6 +2,10 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/path"
@@ -10,7 +14,9 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
+	instance_datasource "github.com/shadeform/terraform-provider-shadeform/internal/datasources/instance"
 	"github.com/shadeform/terraform-provider-shadeform/internal/datasources/instance_types"
+	"github.com/shadeform/terraform-provider-shadeform/internal/datasources/instances"
 	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
 	"github.com/shadeform/terraform-provider-shadeform/internal/resources/instance"
 	"github.com/shadeform/terraform-provider-shadeform/internal/resources/volume"
@@ -28,7 +34,15 @@ type ShadeformProvider struct {
 }
 
 type ShadeformProviderModel struct {
-	ApiKey types.String `tfsdk:"api_key"`
+	ApiKey       types.String `tfsdk:"api_key"`
+	Endpoint     types.String `tfsdk:"endpoint"`
+	MaxRetries   types.Int64  `tfsdk:"max_retries"`
+	MaxRetryWait types.String `tfsdk:"max_retry_wait"`
+
+	RequestsPerSecond    types.Float64 `tfsdk:"requests_per_second"`
+	MaxConcurrentCreates types.Int64   `tfsdk:"max_concurrent_creates"`
+
+	DefaultTags types.Set `tfsdk:"default_tags"`
 }
 
 func (p *ShadeformProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -44,6 +58,31 @@ func (p *ShadeformProvider) Schema(ctx context.Context, req provider.SchemaReque
 				Optional:            true,
 				Sensitive:           true,
 			},
+			"endpoint": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Base URL of the Shadeform API. Can also be set via the SHADEFORM_ENDPOINT environment variable. Defaults to `%s`.", provider_shadeform.DefaultEndpoint),
+				Optional:            true,
+			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum number of times a failed API request is retried. Reads are retried on throttling, gateway errors and connection failures; mutating calls only when the API did not process them. Set to `0` to disable retries. Defaults to `%d`.", provider_shadeform.DefaultMaxRetries),
+				Optional:            true,
+			},
+			"max_retry_wait": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Maximum time to wait between two retries, as a Go duration such as `30s` or `2m`. Also caps waits requested by the API through `Retry-After`. Defaults to `%s`.", provider_shadeform.DefaultMaxRetryWait),
+				Optional:            true,
+			},
+			"requests_per_second": schema.Float64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum sustained rate of API requests, shared by every resource and data source using this provider. Set to `0` to disable rate limiting. Defaults to `%d`.", provider_shadeform.DefaultRequestsPerSecond),
+				Optional:            true,
+			},
+			"default_tags": schema.SetAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Tags added to every instance and volume managed by this provider. They are merged with the `tags` of each resource and shown in its `tags_all` attribute.",
+				Optional:            true,
+			},
+			"max_concurrent_creates": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum number of create, update and delete API calls in flight at the same time, shared by every resource using this provider. Set to `0` to disable the cap. Defaults to `%d`.", provider_shadeform.DefaultMaxConcurrentCreates),
+				Optional:            true,
+			},
 		},
 	}
 }
@@ -57,7 +96,59 @@ func (p *ShadeformProvider) Configure(ctx context.Context, req provider.Configur
 		return
 	}
 
-	client := provider_shadeform.NewClient(data.ApiKey.ValueString())
+	if data.Endpoint.IsNull() {
+		if endpoint := os.Getenv("SHADEFORM_ENDPOINT"); endpoint != "" {
+			if err := validateEndpoint(endpoint); err != nil {
+				resp.Diagnostics.AddError(
+					"Invalid Endpoint",
+					fmt.Sprintf("SHADEFORM_ENDPOINT must be an absolute http or https URL such as %q: %s", provider_shadeform.DefaultEndpoint, err),
+				)
+				return
+			}
+		}
+	}
+
+	opts := provider_shadeform.ClientOptions{
+		MaxRetries:   provider_shadeform.DefaultMaxRetries,
+		MaxRetryWait: provider_shadeform.DefaultMaxRetryWait,
+
+		RequestsPerSecond:    provider_shadeform.DefaultRequestsPerSecond,
+		MaxConcurrentCreates: provider_shadeform.DefaultMaxConcurrentCreates,
+	}
+
+	if !data.MaxRetries.IsNull() && !data.MaxRetries.IsUnknown() {
+		opts.MaxRetries = int(data.MaxRetries.ValueInt64())
+	}
+
+	if !data.MaxRetryWait.IsNull() && !data.MaxRetryWait.IsUnknown() {
+		maxRetryWait, err := time.ParseDuration(data.MaxRetryWait.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_retry_wait"),
+				"Invalid Retry Wait",
+				"Could not parse max_retry_wait: "+err.Error(),
+			)
+			return
+		}
+		opts.MaxRetryWait = maxRetryWait
+	}
+
+	if !data.RequestsPerSecond.IsNull() && !data.RequestsPerSecond.IsUnknown() {
+		opts.RequestsPerSecond = data.RequestsPerSecond.ValueFloat64()
+	}
+
+	if !data.MaxConcurrentCreates.IsNull() && !data.MaxConcurrentCreates.IsUnknown() {
+		opts.MaxConcurrentCreates = int(data.MaxConcurrentCreates.ValueInt64())
+	}
+
+	if !data.DefaultTags.IsNull() && !data.DefaultTags.IsUnknown() {
+		resp.Diagnostics.Append(data.DefaultTags.ElementsAs(ctx, &opts.DefaultTags, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	client := provider_shadeform.NewClient(data.Endpoint.ValueString(), data.ApiKey.ValueString(), opts)
 	resp.DataSourceData = client
 	resp.ResourceData = client
 }
@@ -71,7 +162,9 @@ func (p *ShadeformProvider) Resources(ctx context.Context) []func() resource.Res
 
 func (p *ShadeformProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
+		instance_datasource.NewInstanceDataSource,
 		instance_types.NewInstanceTypesDataSource,
+		instances.NewInstancesDataSource,
 	}
 }
 
@@ -101,4 +194,74 @@ func (p *ShadeformProvider) ValidateConfig(ctx context.Context, req provider.Val
 				"If either is already set, ensure the value is not empty.",
 		)
 	}
+
+	if !data.Endpoint.IsNull() && !data.Endpoint.IsUnknown() {
+		if err := validateEndpoint(data.Endpoint.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("endpoint"),
+				"Invalid Endpoint",
+				fmt.Sprintf("endpoint must be an absolute http or https URL such as %q: %s", provider_shadeform.DefaultEndpoint, err),
+			)
+		}
+	}
+
+	if !data.MaxRetries.IsNull() && !data.MaxRetries.IsUnknown() && data.MaxRetries.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_retries"),
+			"Invalid Max Retries",
+			"max_retries must be zero or a positive number.",
+		)
+	}
+
+	if !data.MaxRetryWait.IsNull() && !data.MaxRetryWait.IsUnknown() {
+		maxRetryWait, err := time.ParseDuration(data.MaxRetryWait.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_retry_wait"),
+				"Invalid Retry Wait",
+				fmt.Sprintf("max_retry_wait must be a valid duration such as \"30s\" or \"2m\": %s", err),
+			)
+		} else if maxRetryWait <= 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_retry_wait"),
+				"Invalid Retry Wait",
+				"max_retry_wait must be a positive duration.",
+			)
+		}
+	}
+
+	if !data.RequestsPerSecond.IsNull() && !data.RequestsPerSecond.IsUnknown() && data.RequestsPerSecond.ValueFloat64() < 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("requests_per_second"),
+			"Invalid Requests Per Second",
+			"requests_per_second must be zero or a positive number.",
+		)
+	}
+
+	if !data.MaxConcurrentCreates.IsNull() && !data.MaxConcurrentCreates.IsUnknown() && data.MaxConcurrentCreates.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_concurrent_creates"),
+			"Invalid Max Concurrent Creates",
+			"max_concurrent_creates must be zero or a positive number.",
+		)
+	}
+}
+
+// validateEndpoint checks that endpoint is an absolute http(s) URL that the
+// client can append API routes to.
+func validateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("query strings and fragments are not supported")
+	}
+	return nil
 }
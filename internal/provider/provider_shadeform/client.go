@@ -2,21 +2,32 @@ package provider_shadeform
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 const (
-	baseURL           = "https://api.shadeform.ai/v1"
+	DefaultEndpoint   = "https://api.shadeform.ai/v1"
 	apiKeyHeader      = "X-API-KEY"
 	contentTypeHeader = "Content-Type"
 	contentTypeJSON   = "application/json"
 
 	// Instance routes
+	instancesRoute      = "/instances"
 	instanceCreateRoute = "/instances/create"
 	instanceInfoRoute   = "/instances/%s/info"
 	instanceUpdateRoute = "/instances/%s/update"
@@ -26,121 +37,359 @@ const (
 	// Volume routes
 	volumeCreateRoute = "/volumes/create"
 	volumeInfoRoute   = "/volumes/%s/info"
+	volumeUpdateRoute = "/volumes/%s/update"
 	volumeDeleteRoute = "/volumes/%s/delete"
+
+	// Retry defaults
+	DefaultMaxRetries   = 4
+	DefaultMaxRetryWait = 30 * time.Second
+	retryBaseWait       = 1 * time.Second
+
+	// Throttling defaults
+	DefaultRequestsPerSecond    = 10
+	DefaultMaxConcurrentCreates = 5
 )
 
+// ClientOptions tunes the behaviour of the API client.
+type ClientOptions struct {
+	// MaxRetries is the number of times a failed request is retried. Zero
+	// disables retries.
+	MaxRetries int
+	// MaxRetryWait caps the wait between two attempts, including waits
+	// requested by the API through Retry-After.
+	MaxRetryWait time.Duration
+	// RequestsPerSecond is the sustained rate of API requests. Zero disables
+	// rate limiting.
+	RequestsPerSecond float64
+	// MaxConcurrentCreates caps the number of mutating requests in flight at
+	// once. Zero disables the cap.
+	MaxConcurrentCreates int
+	// DefaultTags are added to the tags of every instance and volume.
+	DefaultTags []string
+}
+
 type Client struct {
-	apiKey     string
-	httpClient *http.Client
+	endpoint     string
+	apiKey       string
+	httpClient   *http.Client
+	maxRetries   int
+	maxRetryWait time.Duration
+
+	// limiter and mutations are shared by every resource and data source
+	// using this client.
+	limiter   *rateLimiter
+	mutations semaphore
+
+	// poller batches status polling for every instance being waited on.
+	poller *instancePoller
+
+	defaultTags []string
 }
 
-func NewClient(apiKey string) *Client {
+func NewClient(endpoint, apiKey string, opts ClientOptions) *Client {
+	if endpoint == "" {
+		endpoint = os.Getenv("SHADEFORM_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+
 	if apiKey == "" {
 		apiKey = os.Getenv("SHADEFORM_API_KEY")
 	}
 
-	return &Client{
-		apiKey: apiKey,
+	maxRetries := max(opts.MaxRetries, 0)
+
+	maxRetryWait := opts.MaxRetryWait
+	if maxRetryWait <= 0 {
+		maxRetryWait = DefaultMaxRetryWait
+	}
+
+	c := &Client{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		apiKey:   apiKey,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		maxRetries:   maxRetries,
+		maxRetryWait: maxRetryWait,
+		limiter:      newRateLimiter(opts.RequestsPerSecond),
+		mutations:    newSemaphore(opts.MaxConcurrentCreates),
+		defaultTags:  opts.DefaultTags,
 	}
+	c.poller = newInstancePoller(c)
+
+	return c
 }
 
-func (c *Client) CreateInstance(requestBody map[string]interface{}) (map[string]interface{}, error) {
-	return c.makeRequest("POST", instanceCreateRoute, requestBody, true)
+// DefaultTags returns the tags the provider adds to every instance and volume.
+func (c *Client) DefaultTags() []string {
+	return c.defaultTags
 }
 
-func (c *Client) GetInstance(instanceID string) (map[string]interface{}, error) {
-	return c.makeRequest("GET", fmt.Sprintf(instanceInfoRoute, instanceID), nil, true)
+func (c *Client) CreateInstance(ctx context.Context, requestBody *CreateInstanceRequest) (*CreateInstanceResponse, error) {
+	var result CreateInstanceResponse
+	if err := c.makeRequest(ctx, "POST", instanceCreateRoute, requestBody, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
-func (c *Client) UpdateInstance(instanceID string, requestBody map[string]interface{}) error {
-	return c.makeRequestNoResponse("POST", fmt.Sprintf(instanceUpdateRoute, instanceID), requestBody)
+func (c *Client) ListInstances(ctx context.Context) ([]Instance, error) {
+	var result ListInstancesResponse
+	if err := c.makeRequest(ctx, "GET", instancesRoute, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Instances, nil
+}
+
+func (c *Client) GetInstance(ctx context.Context, instanceID string) (*Instance, error) {
+	var result Instance
+	if err := c.makeRequest(ctx, "GET", fmt.Sprintf(instanceInfoRoute, instanceID), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
-func (c *Client) DeleteInstance(instanceID string) error {
-	return c.makeRequestNoResponse("POST", fmt.Sprintf(instanceDeleteRoute, instanceID), nil)
+func (c *Client) UpdateInstance(ctx context.Context, instanceID string, requestBody *UpdateInstanceRequest) error {
+	return c.makeRequest(ctx, "POST", fmt.Sprintf(instanceUpdateRoute, instanceID), requestBody, nil)
 }
 
-func (c *Client) GetInstanceTypes(params map[string]string) (map[string]interface{}, error) {
+func (c *Client) DeleteInstance(ctx context.Context, instanceID string) error {
+	return c.makeRequest(ctx, "POST", fmt.Sprintf(instanceDeleteRoute, instanceID), nil, nil)
+}
+
+func (c *Client) GetInstanceTypes(ctx context.Context, params map[string]string) ([]InstanceType, error) {
 	query := ""
 	if len(params) > 0 {
-		query = "?"
-		first := true
+		values := url.Values{}
 		for key, value := range params {
-			if !first {
-				query += "&"
-			}
-			query += fmt.Sprintf("%s=%s", key, value)
-			first = false
+			values.Set(key, value)
 		}
+		query = "?" + values.Encode()
+	}
+
+	var result InstanceTypesResponse
+	if err := c.makeRequest(ctx, "GET", instanceTypesRoute+query, nil, &result); err != nil {
+		return nil, err
 	}
+	return result.InstanceTypes, nil
+}
 
-	return c.makeRequest("GET", instanceTypesRoute+query, nil, true)
+func (c *Client) CreateVolume(ctx context.Context, requestBody *CreateVolumeRequest) (*CreateVolumeResponse, error) {
+	var result CreateVolumeResponse
+	if err := c.makeRequest(ctx, "POST", volumeCreateRoute, requestBody, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
-func (c *Client) CreateVolume(requestBody map[string]interface{}) (map[string]interface{}, error) {
-	return c.makeRequest("POST", volumeCreateRoute, requestBody, true)
+func (c *Client) GetVolume(ctx context.Context, volumeID string) (*Volume, error) {
+	var result Volume
+	if err := c.makeRequest(ctx, "GET", fmt.Sprintf(volumeInfoRoute, volumeID), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
-func (c *Client) GetVolume(volumeID string) (map[string]interface{}, error) {
-	return c.makeRequest("GET", fmt.Sprintf(volumeInfoRoute, volumeID), nil, true)
+func (c *Client) UpdateVolume(ctx context.Context, volumeID string, requestBody *UpdateVolumeRequest) error {
+	return c.makeRequest(ctx, "POST", fmt.Sprintf(volumeUpdateRoute, volumeID), requestBody, nil)
 }
 
-func (c *Client) DeleteVolume(volumeID string) error {
-	return c.makeRequestNoResponse("POST", fmt.Sprintf(volumeDeleteRoute, volumeID), nil)
+func (c *Client) DeleteVolume(ctx context.Context, volumeID string) error {
+	return c.makeRequest(ctx, "POST", fmt.Sprintf(volumeDeleteRoute, volumeID), nil, nil)
 }
 
-func (c *Client) makeRequest(method, path string, body interface{}, expectResponse bool) (map[string]interface{}, error) {
-	var reqBody io.Reader
+// makeRequest sends body as JSON to path and decodes the response into result.
+// A nil result discards the response body.
+func (c *Client) makeRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+			return fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonData)
 	}
 
-	req, err := http.NewRequest(method, baseURL+path, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Only reads are retried unconditionally. Mutating calls are retried only
+	// when we know the API did not act on them.
+	idempotent := method == http.MethodGet
+
+	logCtx := c.newLogContext(ctx)
+
+	var respBody []byte
+	for attempt := 0; ; attempt++ {
+		var reqBody io.Reader
+		if jsonData != nil {
+			reqBody = bytes.NewReader(jsonData)
+		}
+
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reqBody)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set(apiKeyHeader, c.apiKey)
+		if body != nil {
+			req.Header.Set(contentTypeHeader, contentTypeJSON)
+		}
+
+		// Mutating calls hold a slot of the cap for a single attempt only, so
+		// that one waiting to be retried does not hold up the others
+		if !idempotent {
+			if err := c.mutations.Acquire(ctx); err != nil {
+				return err
+			}
+		}
+
+		logRequest(logCtx, req, jsonData, attempt)
+
+		start := time.Now()
+		resp, err := c.httpClient.Do(req)
+		var readErr error
+		if err == nil {
+			respBody, readErr = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+
+		if !idempotent {
+			c.mutations.Release()
+		}
+
+		if err != nil {
+			tflog.SubsystemDebug(logCtx, logSubsystem, "API request failed", map[string]interface{}{
+				"http_method": method,
+				"http_path":   req.URL.Path,
+				"error":       err.Error(),
+			})
+			if ctx.Err() == nil && attempt < c.maxRetries && shouldRetryError(err, idempotent) {
+				if err := sleepContext(ctx, c.retryWait(attempt, nil)); err != nil {
+					return err
+				}
+				continue
+			}
+			return fmt.Errorf("failed to make request: %w", err)
+		}
+
+		if readErr != nil {
+			if attempt < c.maxRetries && idempotent {
+				if err := sleepContext(ctx, c.retryWait(attempt, nil)); err != nil {
+					return err
+				}
+				continue
+			}
+			return fmt.Errorf("failed to read response body: %w", readErr)
+		}
+
+		logResponse(logCtx, req, resp, respBody, time.Since(start))
+
+		if resp.StatusCode == http.StatusOK {
+			break
+		}
+
+		if attempt < c.maxRetries && shouldRetryStatus(resp.StatusCode, idempotent) {
+			if err := sleepContext(ctx, c.retryWait(attempt, resp)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		return newAPIError(resp, respBody)
 	}
 
-	req.Header.Set(apiKeyHeader, c.apiKey)
-	if body != nil {
-		req.Header.Set(contentTypeHeader, contentTypeJSON)
+	// If we don't expect a response (like for delete operations), return early
+	if result == nil {
+		return nil
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	return nil
+}
+
+// shouldRetryStatus reports whether a response with the given status code is
+// worth another attempt. A 429 means the API rejected the request outright, so
+// it is safe to retry for every method; gateway errors are only retried for
+// idempotent calls since the request may already have been processed.
+func shouldRetryStatus(statusCode int, idempotent bool) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	default:
+		return false
 	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+// shouldRetryError reports whether a transport error is worth another attempt.
+// Idempotent calls are retried on any network error. Mutating calls are only
+// retried when the connection could not be established, since the request
+// never reached the API.
+func shouldRetryError(err error, idempotent bool) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	if !idempotent {
+		return false
 	}
 
-	// If we don't expect a response (like for delete operations), return early
-	if !expectResponse {
-		return nil, nil
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
 	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+// retryWait returns how long to wait before the next attempt. A Retry-After
+// header on resp takes precedence, otherwise the wait grows exponentially with
+// full jitter. Both are capped at maxRetryWait.
+func (c *Client) retryWait(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(wait, c.maxRetryWait)
+		}
 	}
 
-	return result, nil
+	backoff := retryBaseWait << attempt
+	if backoff <= 0 || backoff > c.maxRetryWait {
+		backoff = c.maxRetryWait
+	}
+	return time.Duration(rand.Int63n(int64(backoff)))
 }
 
-func (c *Client) makeRequestNoResponse(method, path string, body interface{}) error {
-	_, err := c.makeRequest(method, path, body, false)
-	return err
+// parseRetryAfter parses a Retry-After header given either in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
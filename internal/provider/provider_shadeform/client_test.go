@@ -0,0 +1,250 @@
+package provider_shadeform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestShouldRetryStatus(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		idempotent bool
+		want       bool
+	}{
+		{http.StatusTooManyRequests, true, true},
+		{http.StatusTooManyRequests, false, true},
+		{http.StatusBadGateway, true, true},
+		{http.StatusBadGateway, false, false},
+		{http.StatusServiceUnavailable, true, true},
+		{http.StatusServiceUnavailable, false, false},
+		{http.StatusGatewayTimeout, true, true},
+		{http.StatusGatewayTimeout, false, false},
+		{http.StatusInternalServerError, true, false},
+		{http.StatusBadRequest, true, false},
+		{http.StatusNotFound, true, false},
+		{http.StatusOK, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d/idempotent=%t", tt.statusCode, tt.idempotent), func(t *testing.T) {
+			if got := shouldRetryStatus(tt.statusCode, tt.idempotent); got != tt.want {
+				t.Errorf("shouldRetryStatus(%d, %t) = %t, want %t", tt.statusCode, tt.idempotent, got, tt.want)
+			}
+		})
+	}
+}
+
+// timeoutError is a net.Error that is not a dial error.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestShouldRetryError(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+	tests := []struct {
+		name       string
+		err        error
+		idempotent bool
+		want       bool
+	}{
+		{"dial error on GET", dialErr, true, true},
+		{"dial error on POST", dialErr, false, true},
+		{"wrapped dial error on POST", fmt.Errorf("failed to make request: %w", dialErr), false, true},
+		{"read error on GET", readErr, true, true},
+		{"read error on POST", readErr, false, false},
+		{"timeout on GET", timeoutError{}, true, true},
+		{"timeout on POST", timeoutError{}, false, false},
+		{"connection reset on GET", syscall.ECONNRESET, true, true},
+		{"unexpected EOF on GET", io.ErrUnexpectedEOF, true, true},
+		{"EOF on GET", io.EOF, true, true},
+		{"EOF on POST", io.EOF, false, false},
+		{"other error on GET", errors.New("boom"), true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldRetryError(tt.err, tt.idempotent); got != tt.want {
+				t.Errorf("shouldRetryError(%v, %t) = %t, want %t", tt.err, tt.idempotent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"empty", "", 0, false},
+		{"seconds", "5", 5 * time.Second, true},
+		{"zero", "0", 0, true},
+		{"negative", "-1", 0, false},
+		{"garbage", "soon", 0, false},
+		{"date in the past", "Mon, 02 Jan 2006 15:04:05 GMT", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %s, %t, want %s, %t", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	t.Run("date in the future", func(t *testing.T) {
+		value := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+		got, ok := parseRetryAfter(value)
+		if !ok || got <= 0 || got > time.Minute {
+			t.Errorf("parseRetryAfter(%q) = %s, %t, want up to 1m, true", value, got, ok)
+		}
+	})
+}
+
+func TestRetryWait(t *testing.T) {
+	c := NewClient("http://localhost", "key", ClientOptions{MaxRetryWait: 10 * time.Second})
+
+	withRetryAfter := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+	}
+
+	if got := c.retryWait(0, withRetryAfter("3")); got != 3*time.Second {
+		t.Errorf("Retry-After 3: got %s, want 3s", got)
+	}
+	if got := c.retryWait(0, withRetryAfter("120")); got != 10*time.Second {
+		t.Errorf("Retry-After 120: got %s, want it capped at 10s", got)
+	}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		limit := min(retryBaseWait<<attempt, 10*time.Second)
+		for i := 0; i < 20; i++ {
+			if got := c.retryWait(attempt, nil); got < 0 || got >= limit {
+				t.Fatalf("attempt %d: got %s, want in [0, %s)", attempt, got, limit)
+			}
+		}
+	}
+}
+
+func TestMakeRequestRetriesPostOnTooManyRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"vol-1"}`)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key", ClientOptions{MaxRetries: 2})
+	result, err := c.CreateVolume(context.Background(), &CreateVolumeRequest{Name: "test"})
+	if err != nil {
+		t.Fatalf("CreateVolume: %s", err)
+	}
+	if result.ID != "vol-1" {
+		t.Errorf("got ID %q, want vol-1", result.ID)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("got %d calls, want 2", got)
+	}
+}
+
+func TestMakeRequestDoesNotRetryPostOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key", ClientOptions{MaxRetries: 2})
+	_, err := c.CreateVolume(context.Background(), &CreateVolumeRequest{Name: "test"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got error %v, want a 503 APIError", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("got %d calls, want 1", got)
+	}
+}
+
+func TestMakeRequestRetriesGetOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := calls.Add(1); n < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"vol-1","size_in_gb":100}`)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key", ClientOptions{MaxRetries: 2})
+	volume, err := c.GetVolume(context.Background(), "vol-1")
+	if err != nil {
+		t.Fatalf("GetVolume: %s", err)
+	}
+	if volume.SizeInGB != 100 {
+		t.Errorf("got size %d, want 100", volume.SizeInGB)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("got %d calls, want 3", got)
+	}
+}
+
+func TestMakeRequestReleasesMutationSlotWhileWaiting(t *testing.T) {
+	throttled := make(chan struct{})
+	var createCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == volumeCreateRoute && createCalls.Add(1) == 1 {
+			close(throttled)
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"vol-1"}`)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key", ClientOptions{MaxRetries: 1, MaxConcurrentCreates: 1})
+
+	created := make(chan error, 1)
+	go func() {
+		_, err := c.CreateVolume(context.Background(), &CreateVolumeRequest{Name: "test"})
+		created <- err
+	}()
+	<-throttled
+
+	// The create waits a second to be retried, the delete must not wait on it
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := c.DeleteVolume(ctx, "vol-2"); err != nil {
+		t.Fatalf("DeleteVolume while a create is waiting to be retried: %s", err)
+	}
+
+	if err := <-created; err != nil {
+		t.Fatalf("CreateVolume: %s", err)
+	}
+}
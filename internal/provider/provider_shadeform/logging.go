@@ -0,0 +1,156 @@
+package provider_shadeform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tfsdklog"
+)
+
+const (
+	// logSubsystem is the tflog subsystem HTTP traffic is logged under. Its
+	// level can be raised independently of the rest of the provider with
+	// TF_LOG_PROVIDER_SHADEFORM_API.
+	logSubsystem      = "shadeform_api"
+	logSubsystemLevel = "TF_LOG_PROVIDER_SHADEFORM_API"
+
+	// providerLoggerName and providerLogLevel match the provider logger the
+	// plugin server sets up, whose level is read from TF_LOG_PROVIDER_SHADEFORM.
+	providerLoggerName = "shadeform"
+	providerLogLevel   = "TF_LOG_PROVIDER"
+
+	redactedValue = "***"
+)
+
+// sensitiveBodyKeys are JSON keys whose values are never written to the logs,
+// wherever they appear in a request or response body.
+var sensitiveBodyKeys = map[string]bool{
+	"api_key":       true,
+	"base64_script": true,
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+}
+
+// sensitiveListKeys are JSON keys holding a list of name/value objects whose
+// values are never written to the logs.
+var sensitiveListKeys = map[string]bool{
+	"envs": true,
+}
+
+// newBackgroundLogContext returns a context carrying a provider logger set up
+// like the one the plugin server attaches to every RPC, but without the fields
+// of any request. It is meant for API calls made on behalf of several
+// resources at once.
+func newBackgroundLogContext() context.Context {
+	return tfsdklog.NewRootProviderLogger(context.Background(),
+		tfsdklog.WithStderrFromInit(),
+		tfsdklog.WithLogName(providerLoggerName),
+		tflog.WithLevelFromEnv(providerLogLevel, providerLoggerName),
+	)
+}
+
+// newLogContext returns ctx with the API logging subsystem attached and the
+// API key masked from every field.
+func (c *Client) newLogContext(ctx context.Context) context.Context {
+	ctx = tflog.NewSubsystem(ctx, logSubsystem, tflog.WithLevelFromEnv(logSubsystemLevel))
+	if c.apiKey != "" {
+		ctx = tflog.SubsystemMaskAllFieldValuesStrings(ctx, logSubsystem, c.apiKey)
+	}
+	return ctx
+}
+
+// logRequest emits an outgoing request.
+func logRequest(ctx context.Context, req *http.Request, body []byte, attempt int) {
+	fields := map[string]interface{}{
+		"http_method": req.Method,
+		"http_path":   req.URL.Path,
+		"attempt":     attempt + 1,
+	}
+	tflog.SubsystemDebug(ctx, logSubsystem, "Sending API request", fields)
+
+	if body != nil {
+		tflog.SubsystemTrace(ctx, logSubsystem, "API request body", map[string]interface{}{
+			"http_method": req.Method,
+			"http_path":   req.URL.Path,
+			"http_body":   redactBody(body),
+		})
+	}
+}
+
+// logResponse emits a received response.
+func logResponse(ctx context.Context, req *http.Request, resp *http.Response, body []byte, latency time.Duration) {
+	tflog.SubsystemDebug(ctx, logSubsystem, "Received API response", map[string]interface{}{
+		"http_method":     req.Method,
+		"http_path":       req.URL.Path,
+		"http_status":     resp.StatusCode,
+		"http_latency_ms": latency.Milliseconds(),
+		"request_id":      resp.Header.Get(requestIDHeader),
+	})
+
+	tflog.SubsystemTrace(ctx, logSubsystem, "API response body", map[string]interface{}{
+		"http_method": req.Method,
+		"http_path":   req.URL.Path,
+		"http_body":   redactBody(body),
+	})
+}
+
+// redactBody masks sensitive values in a JSON body. Bodies that are not JSON
+// are returned unchanged.
+func redactBody(body []byte) string {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return strings.TrimSpace(string(body))
+	}
+
+	redacted, err := json.Marshal(redactValue(decoded))
+	if err != nil {
+		return redactedValue
+	}
+	return string(redacted)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			switch {
+			case sensitiveBodyKeys[key]:
+				v[key] = redactedValue
+			case sensitiveListKeys[key]:
+				v[key] = redactNameValueList(value)
+			default:
+				v[key] = redactValue(value)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// redactNameValueList masks the value of every {"name": ..., "value": ...}
+// entry, keeping the names so logs still show which variables were set.
+func redactNameValueList(v interface{}) interface{} {
+	list, ok := v.([]interface{})
+	if !ok {
+		return redactedValue
+	}
+	for _, item := range list {
+		if entry, ok := item.(map[string]interface{}); ok {
+			if _, ok := entry["value"]; ok {
+				entry["value"] = redactedValue
+			}
+		}
+	}
+	return list
+}
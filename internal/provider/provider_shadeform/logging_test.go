@@ -0,0 +1,80 @@
+package provider_shadeform
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "no secrets",
+			body: `{"name":"test","size_in_gb":100}`,
+			want: `{"name":"test","size_in_gb":100}`,
+		},
+		{
+			name: "top level keys",
+			body: `{"api_key":"k","token":"t","secret":"s","name":"test"}`,
+			want: `{"api_key":"***","token":"***","secret":"***","name":"test"}`,
+		},
+		{
+			name: "nested registry password",
+			body: `{"launch_configuration":{"docker_configuration":{"registry_credentials":{"username":"u","password":"p"}}}}`,
+			want: `{"launch_configuration":{"docker_configuration":{"registry_credentials":{"username":"u","password":"***"}}}}`,
+		},
+		{
+			name: "startup script",
+			body: `{"launch_configuration":{"type":"script","script_configuration":{"base64_script":"ZWNobyBoaQ=="}}}`,
+			want: `{"launch_configuration":{"type":"script","script_configuration":{"base64_script":"***"}}}`,
+		},
+		{
+			name: "env values keep their names",
+			body: `{"envs":[{"name":"A","value":"1"},{"name":"B","value":"2"}]}`,
+			want: `{"envs":[{"name":"A","value":"***"},{"name":"B","value":"***"}]}`,
+		},
+		{
+			name: "nested envs",
+			body: `{"docker_configuration":{"envs":[{"name":"A","value":"1"}]}}`,
+			want: `{"docker_configuration":{"envs":[{"name":"A","value":"***"}]}}`,
+		},
+		{
+			name: "envs that are not a list",
+			body: `{"envs":"A=1"}`,
+			want: `{"envs":"***"}`,
+		},
+		{
+			name: "secrets in a list of objects",
+			body: `{"instances":[{"id":"a","token":"t"}]}`,
+			want: `{"instances":[{"id":"a","token":"***"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactBody([]byte(tt.body))
+
+			// Compare decoded values, since key order is not preserved
+			var gotValue, wantValue interface{}
+			if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+				t.Fatalf("redactBody returned invalid JSON %q: %s", got, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantValue); err != nil {
+				t.Fatalf("invalid want %q: %s", tt.want, err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("redactBody(%s) = %s, want %s", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactBodyNotJSON(t *testing.T) {
+	if got := redactBody([]byte("  bad gateway\n")); got != "bad gateway" {
+		t.Errorf("redactBody = %q, want %q", got, "bad gateway")
+	}
+}
@@ -0,0 +1,270 @@
+package provider_shadeform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Instance statuses reported by the API.
+const (
+	InstanceStatusActive  = "active"
+	InstanceStatusError   = "error"
+	InstanceStatusDeleted = "deleted"
+)
+
+// Instance is the payload returned by the instance info route.
+type Instance struct {
+	ID                string     `json:"id"`
+	Cloud             string     `json:"cloud"`
+	Region            string     `json:"region"`
+	ShadeInstanceType string     `json:"shade_instance_type"`
+	CloudInstanceType string     `json:"cloud_instance_type"`
+	CloudAssignedID   string     `json:"cloud_assigned_id"`
+	ShadeCloud        *bool      `json:"shade_cloud"`
+	Name              string     `json:"name"`
+	Os                string     `json:"os"`
+	TemplateID        string     `json:"template_id"`
+	SshKeyID          string     `json:"ssh_key_id"`
+	VolumeIDs         []string   `json:"volume_ids"`
+	IP                string     `json:"ip"`
+	SshUser           string     `json:"ssh_user"`
+	SshPort           int64      `json:"ssh_port"`
+	Status            string     `json:"status"`
+	CostEstimate      FlexString `json:"cost_estimate"`
+	HourlyPrice       FlexString `json:"hourly_price"`
+	CreatedAt         string     `json:"created_at"`
+	Tags              []string   `json:"tags"`
+
+	LaunchConfiguration *LaunchConfiguration `json:"launch_configuration"`
+	AutoDelete          *Thresholds          `json:"auto_delete"`
+	Alert               *Thresholds          `json:"alert"`
+}
+
+// ListInstancesResponse is the payload returned by the instance list route.
+type ListInstancesResponse struct {
+	Instances []Instance `json:"instances"`
+}
+
+// CreateInstanceRequest is the body sent to the instance create route.
+type CreateInstanceRequest struct {
+	Cloud             string   `json:"cloud"`
+	Region            string   `json:"region"`
+	ShadeInstanceType string   `json:"shade_instance_type"`
+	ShadeCloud        *bool    `json:"shade_cloud,omitempty"`
+	Name              string   `json:"name"`
+	Os                string   `json:"os,omitempty"`
+	TemplateID        string   `json:"template_id,omitempty"`
+	SshKeyID          string   `json:"ssh_key_id,omitempty"`
+	VolumeIDs         []string `json:"volume_ids,omitempty"`
+	Envs              []EnvVar `json:"envs,omitempty"`
+	Tags              []string `json:"tags,omitempty"`
+
+	LaunchConfiguration *LaunchConfiguration `json:"launch_configuration,omitempty"`
+	AutoDelete          *Thresholds          `json:"auto_delete,omitempty"`
+	Alert               *Thresholds          `json:"alert,omitempty"`
+}
+
+// Launch configuration types.
+const (
+	LaunchConfigurationTypeDocker = "docker"
+	LaunchConfigurationTypeScript = "script"
+)
+
+// LaunchConfiguration describes what an instance runs once it has booted.
+type LaunchConfiguration struct {
+	Type                string               `json:"type"`
+	DockerConfiguration *DockerConfiguration `json:"docker_configuration,omitempty"`
+	ScriptConfiguration *ScriptConfiguration `json:"script_configuration,omitempty"`
+}
+
+// DockerConfiguration runs a container on the instance.
+type DockerConfiguration struct {
+	Image               string               `json:"image"`
+	Args                string               `json:"args,omitempty"`
+	SharedMemoryInGB    int64                `json:"shared_memory_in_gb,omitempty"`
+	Envs                []EnvVar             `json:"envs,omitempty"`
+	PortMappings        []PortMapping        `json:"port_mappings,omitempty"`
+	VolumeMounts        []VolumeMount        `json:"volume_mounts,omitempty"`
+	RegistryCredentials *RegistryCredentials `json:"registry_credentials,omitempty"`
+}
+
+// ScriptConfiguration runs a shell script on the instance once it has booted.
+type ScriptConfiguration struct {
+	Base64Script string `json:"base64_script"`
+}
+
+// EnvVar is an environment variable.
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PortMapping exposes a container port on the host.
+type PortMapping struct {
+	HostPort      int64 `json:"host_port"`
+	ContainerPort int64 `json:"container_port"`
+}
+
+// VolumeMount mounts a host path into the container.
+type VolumeMount struct {
+	HostPath      string `json:"host_path"`
+	ContainerPath string `json:"container_path"`
+}
+
+// RegistryCredentials authenticate image pulls from a private registry.
+type RegistryCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// CreateInstanceResponse is the payload returned by the instance create route.
+type CreateInstanceResponse struct {
+	ID              string `json:"id"`
+	CloudAssignedID string `json:"cloud_assigned_id"`
+}
+
+// UpdateInstanceRequest is the body sent to the instance update route. Only
+// non-nil fields are changed.
+type UpdateInstanceRequest struct {
+	Name       *string     `json:"name,omitempty"`
+	Tags       *[]string   `json:"tags,omitempty"`
+	AutoDelete *Thresholds `json:"auto_delete,omitempty"`
+	Alert      *Thresholds `json:"alert,omitempty"`
+}
+
+// Thresholds trigger an action on an instance once a date or an amount spent
+// in USD is reached. Empty fields are not set.
+type Thresholds struct {
+	DateThreshold  string     `json:"date_threshold,omitempty"`
+	SpendThreshold FlexString `json:"spend_threshold,omitempty"`
+}
+
+// Volume is the payload returned by the volume info route.
+type Volume struct {
+	ID                 string     `json:"id"`
+	Cloud              string     `json:"cloud"`
+	Region             string     `json:"region"`
+	Name               string     `json:"name"`
+	SizeInGB           int64      `json:"size_in_gb"`
+	FixedSize          bool       `json:"fixed_size"`
+	SupportsMultiMount bool       `json:"supports_multi_mount"`
+	CostEstimate       FlexString `json:"cost_estimate"`
+	MountedBy          string     `json:"mounted_by"`
+	Tags               []string   `json:"tags"`
+}
+
+// CreateVolumeRequest is the body sent to the volume create route.
+type CreateVolumeRequest struct {
+	Cloud    string   `json:"cloud"`
+	Region   string   `json:"region"`
+	Name     string   `json:"name"`
+	SizeInGB int64    `json:"size_in_gb"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// UpdateVolumeRequest is the body sent to the volume update route. Only
+// non-nil fields are changed.
+type UpdateVolumeRequest struct {
+	Tags *[]string `json:"tags,omitempty"`
+}
+
+// CreateVolumeResponse is the payload returned by the volume create route.
+type CreateVolumeResponse struct {
+	ID string `json:"id"`
+}
+
+// InstanceTypesResponse is the payload returned by the instance types route.
+type InstanceTypesResponse struct {
+	InstanceTypes []InstanceType `json:"instance_types"`
+}
+
+// InstanceType describes a purchasable instance type and where it is
+// available.
+type InstanceType struct {
+	Cloud             string                    `json:"cloud"`
+	Region            string                    `json:"region"`
+	ShadeInstanceType string                    `json:"shade_instance_type"`
+	CloudInstanceType string                    `json:"cloud_instance_type"`
+	HourlyPrice       FlexInt64                 `json:"hourly_price"`
+	DeploymentType    string                    `json:"deployment_type"`
+	Configuration     InstanceTypeConfiguration `json:"configuration"`
+	Availability      []Availability            `json:"availability"`
+	BootTime          *BootTime                 `json:"boot_time"`
+}
+
+// InstanceTypeConfiguration holds the hardware details of an instance type.
+type InstanceTypeConfiguration struct {
+	OsOptions []string `json:"os_options"`
+}
+
+// Availability reports whether an instance type can be launched in a region.
+type Availability struct {
+	Region      string `json:"region"`
+	Available   bool   `json:"available"`
+	DisplayName string `json:"display_name"`
+}
+
+// BootTime is the advertised boot duration range of an instance type.
+type BootTime struct {
+	MinBootInSec int64 `json:"min_boot_in_sec"`
+	MaxBootInSec int64 `json:"max_boot_in_sec"`
+}
+
+// FlexString decodes a JSON string or number into its string form. The API is
+// not consistent about quoting prices, so they are accepted either way.
+type FlexString string
+
+func (f *FlexString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*f = ""
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*f = FlexString(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*f = FlexString(n.String())
+	return nil
+}
+
+// FlexInt64 decodes a JSON number or a quoted number into an int64, dropping
+// any fractional part. Like FlexString, it accepts prices however the API
+// happens to send them.
+type FlexInt64 int64
+
+func (f *FlexInt64) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*f = 0
+		return nil
+	}
+
+	value := string(data)
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		value = strings.TrimSpace(s)
+		if value == "" {
+			*f = 0
+			return nil
+		}
+	}
+
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		*f = FlexInt64(n)
+		return nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("cannot decode %s as a number", data)
+	}
+	*f = FlexInt64(n)
+	return nil
+}
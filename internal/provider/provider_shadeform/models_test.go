@@ -0,0 +1,70 @@
+package provider_shadeform
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFlexInt64(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    FlexInt64
+		wantErr bool
+	}{
+		{`210`, 210, false},
+		{`210.9`, 210, false},
+		{`"210"`, 210, false},
+		{`"210.5"`, 210, false},
+		{`" 210 "`, 210, false},
+		{`""`, 0, false},
+		{`null`, 0, false},
+		{`"cheap"`, 0, true},
+		{`true`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var got FlexInt64
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, want error %t", tt.json, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Unmarshal(%s) = %d, want %d", tt.json, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetInstanceTypesQuotedPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"instance_types":[
+			{"cloud":"aws","shade_instance_type":"A100","hourly_price":"210.5","boot_time":{"min_boot_in_sec":300,"max_boot_in_sec":600}},
+			{"cloud":"lambda","shade_instance_type":"H100","hourly_price":249}
+		]}`)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key", ClientOptions{})
+	instanceTypes, err := c.GetInstanceTypes(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("GetInstanceTypes: %s", err)
+	}
+	if len(instanceTypes) != 2 {
+		t.Fatalf("got %d instance types, want 2", len(instanceTypes))
+	}
+	if got := instanceTypes[0].HourlyPrice; got != 210 {
+		t.Errorf("got quoted price %d, want 210", got)
+	}
+	if got := instanceTypes[1].HourlyPrice; got != 249 {
+		t.Errorf("got price %d, want 249", got)
+	}
+	if instanceTypes[0].BootTime == nil || instanceTypes[0].BootTime.MaxBootInSec != 600 {
+		t.Errorf("got boot time %+v, want a maximum of 600s", instanceTypes[0].BootTime)
+	}
+}
@@ -0,0 +1,197 @@
+package provider_shadeform
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// pollerTick is how often the poller checks whether any watcher is due.
+	// It does not call the API unless a watcher is due.
+	pollerTick = 1 * time.Second
+
+	// pollerRequestTimeout bounds the API calls made on a single tick.
+	pollerRequestTimeout = 1 * time.Minute
+)
+
+// InstanceUpdate is a snapshot of a watched instance delivered by the poller.
+// Err is set if the instance is not found. Other failures, such as a server
+// error from the list call, are logged and the watcher is retried when it is
+// next due, so one bad response does not fail every instance being waited on.
+type InstanceUpdate struct {
+	Instance *Instance
+	Err      error
+}
+
+// instanceWatcher is a single WatchInstance registration. interval doubles
+// every time the watcher is due until it reaches maxInterval.
+type instanceWatcher struct {
+	id          string
+	interval    time.Duration
+	maxInterval time.Duration
+	next        time.Time
+	updates     chan InstanceUpdate
+}
+
+// instancePoller fetches the instance list once whenever any watcher is due
+// and hands the result to every watcher, so the number of API calls no longer
+// grows with the number of instances being created. Each watcher keeps its
+// own schedule.
+type instancePoller struct {
+	client *Client
+
+	mu       sync.Mutex
+	watchers map[*instanceWatcher]struct{}
+	running  bool
+
+	// logCtx carries a provider logger without the fields of any request,
+	// since every poll serves several resources.
+	logCtx context.Context
+}
+
+func newInstancePoller(client *Client) *instancePoller {
+	return &instancePoller{
+		client:   client,
+		watchers: make(map[*instanceWatcher]struct{}),
+		logCtx:   newBackgroundLogContext(),
+	}
+}
+
+// WatchInstance delivers a snapshot of the instance at least every interval
+// until the returned stop function is called. Snapshots may arrive sooner when
+// another watcher is due first, since all watchers share each list call. Only
+// the latest snapshot is kept if the caller falls behind.
+func (c *Client) WatchInstance(instanceID string, interval time.Duration) (<-chan InstanceUpdate, func()) {
+	return c.poller.watch(instanceID, interval, interval)
+}
+
+// WatchInstanceWithBackoff is like WatchInstance, but doubles the interval
+// after every time the watcher is due until it reaches maxInterval.
+func (c *Client) WatchInstanceWithBackoff(instanceID string, interval, maxInterval time.Duration) (<-chan InstanceUpdate, func()) {
+	return c.poller.watch(instanceID, interval, max(interval, maxInterval))
+}
+
+func (p *instancePoller) watch(id string, interval, maxInterval time.Duration) (<-chan InstanceUpdate, func()) {
+	w := &instanceWatcher{
+		id:          id,
+		interval:    interval,
+		maxInterval: maxInterval,
+		next:        time.Now().Add(interval),
+		updates:     make(chan InstanceUpdate, 1),
+	}
+
+	p.mu.Lock()
+	p.watchers[w] = struct{}{}
+	if !p.running {
+		p.running = true
+		go p.run()
+	}
+	p.mu.Unlock()
+
+	stop := func() {
+		p.mu.Lock()
+		delete(p.watchers, w)
+		p.mu.Unlock()
+	}
+
+	return w.updates, stop
+}
+
+// run polls until no watchers are left.
+func (p *instancePoller) run() {
+	ticker := time.NewTicker(pollerTick)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.mu.Lock()
+		if len(p.watchers) == 0 {
+			p.running = false
+			p.mu.Unlock()
+			return
+		}
+
+		now := time.Now()
+		var due, waiting []*instanceWatcher
+		for w := range p.watchers {
+			if now.Before(w.next) {
+				waiting = append(waiting, w)
+			} else {
+				due = append(due, w)
+			}
+		}
+		p.mu.Unlock()
+
+		if len(due) > 0 {
+			p.poll(due, waiting)
+		}
+	}
+}
+
+// poll lists instances once on behalf of the due watchers and fans the result
+// out to them, as well as to the waiting watchers found in the list. Only the
+// due watchers move on to their next interval. Instances of due watchers that
+// are missing from the list are fetched individually so that watchers still
+// learn when an instance is gone.
+func (p *instancePoller) poll(due, waiting []*instanceWatcher) {
+	ctx, cancel := context.WithTimeout(p.logCtx, pollerRequestTimeout)
+	defer cancel()
+
+	instances, listErr := p.client.ListInstances(ctx)
+
+	byID := make(map[string]*Instance, len(instances))
+	for i := range instances {
+		byID[instances[i].ID] = &instances[i]
+	}
+
+	if listErr != nil {
+		tflog.Warn(ctx, fmt.Sprintf("could not list instances, retrying: %s", listErr))
+	}
+
+	for _, w := range due {
+		var update InstanceUpdate
+		var err error
+		if listErr != nil {
+			err = listErr
+		} else if instance, ok := byID[w.id]; ok {
+			update.Instance = instance
+		} else {
+			update.Instance, err = p.client.GetInstance(ctx, w.id)
+			if err != nil && !IsNotFound(err) {
+				tflog.Warn(ctx, fmt.Sprintf("could not get instance %s, retrying: %s", w.id, err))
+			}
+		}
+
+		// A failed poll keeps the interval, so the watcher is retried at the
+		// same pace
+		p.mu.Lock()
+		if err == nil {
+			w.interval = min(2*w.interval, w.maxInterval)
+		}
+		w.next = time.Now().Add(w.interval)
+		p.mu.Unlock()
+
+		if err == nil || IsNotFound(err) {
+			update.Err = err
+			w.deliver(update)
+		}
+	}
+
+	for _, w := range waiting {
+		if instance, ok := byID[w.id]; ok {
+			w.deliver(InstanceUpdate{Instance: instance})
+		}
+	}
+}
+
+// deliver replaces any unread snapshot with update without blocking.
+func (w *instanceWatcher) deliver(update InstanceUpdate) {
+	select {
+	case <-w.updates:
+	default:
+	}
+	w.updates <- update
+}
@@ -0,0 +1,233 @@
+package provider_shadeform
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pollerTestTimeout is how long a test waits for the poller, which only
+// checks its watchers once per pollerTick.
+const pollerTestTimeout = 5 * pollerTick
+
+// instanceListServer serves a list of active instances and counts the list
+// and info calls made against it.
+type instanceListServer struct {
+	*httptest.Server
+	listCalls atomic.Int32
+	infoCalls atomic.Int32
+}
+
+func newInstanceListServer(t *testing.T, ids ...string) *instanceListServer {
+	t.Helper()
+
+	s := &instanceListServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != instancesRoute {
+			s.infoCalls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		s.listCalls.Add(1)
+		instances := make([]string, 0, len(ids))
+		for _, id := range ids {
+			instances = append(instances, `{"id":"`+id+`","status":"active"}`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"instances":[`+strings.Join(instances, ",")+`]}`)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// receive waits for the next update on updates.
+func receive(t *testing.T, updates <-chan InstanceUpdate) InstanceUpdate {
+	t.Helper()
+
+	select {
+	case update := <-updates:
+		return update
+	case <-time.After(pollerTestTimeout):
+		t.Fatal("timed out waiting for an instance update")
+		return InstanceUpdate{}
+	}
+}
+
+// waitForPollerStopped waits until the poller of c has exited.
+func waitForPollerStopped(t *testing.T, c *Client) {
+	t.Helper()
+
+	deadline := time.Now().Add(pollerTestTimeout)
+	for time.Now().Before(deadline) {
+		c.poller.mu.Lock()
+		running := c.poller.running
+		c.poller.mu.Unlock()
+		if !running {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("poller did not exit after its last watcher stopped")
+}
+
+func TestPollerSharesListAcrossWatchers(t *testing.T) {
+	server := newInstanceListServer(t, "i-1", "i-2", "i-3")
+	c := NewClient(server.URL, "key", ClientOptions{})
+
+	var stops []func()
+	var channels []<-chan InstanceUpdate
+	for _, id := range []string{"i-1", "i-2", "i-3"} {
+		updates, stop := c.WatchInstance(id, time.Millisecond)
+		stops = append(stops, stop)
+		channels = append(channels, updates)
+	}
+	defer func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}()
+
+	for i, updates := range channels {
+		update := receive(t, updates)
+		if update.Err != nil {
+			t.Fatalf("watcher %d: unexpected error: %s", i, update.Err)
+		}
+		if update.Instance.Status != InstanceStatusActive {
+			t.Errorf("watcher %d: got status %q, want %q", i, update.Instance.Status, InstanceStatusActive)
+		}
+	}
+
+	if got := server.listCalls.Load(); got != 1 {
+		t.Errorf("got %d list calls, want 1", got)
+	}
+	if got := server.infoCalls.Load(); got != 0 {
+		t.Errorf("got %d info calls, want 0", got)
+	}
+}
+
+func TestPollerBacksOffOnlyDueWatchers(t *testing.T) {
+	server := newInstanceListServer(t, "i-1", "i-2")
+	c := NewClient(server.URL, "key", ClientOptions{})
+
+	_, stopDue := c.WatchInstanceWithBackoff("i-1", time.Millisecond, time.Hour)
+	defer stopDue()
+	updates, stopWaiting := c.WatchInstanceWithBackoff("i-2", time.Hour, time.Hour)
+	defer stopWaiting()
+
+	// The waiting watcher gets the shared snapshot without moving on to its
+	// next interval
+	receive(t, updates)
+
+	c.poller.mu.Lock()
+	defer c.poller.mu.Unlock()
+	for w := range c.poller.watchers {
+		want := time.Hour
+		if w.id == "i-1" {
+			want = 2 * time.Millisecond
+		}
+		if w.interval != want {
+			t.Errorf("watcher %s: got interval %s, want %s", w.id, w.interval, want)
+		}
+	}
+}
+
+func TestPollerRetriesFailedList(t *testing.T) {
+	var listCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if listCalls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"instances":[{"id":"i-1","status":"active"}]}`)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key", ClientOptions{})
+	updates, stop := c.WatchInstance("i-1", time.Millisecond)
+	defer stop()
+
+	update := receive(t, updates)
+	if update.Err != nil {
+		t.Fatalf("unexpected error: %s", update.Err)
+	}
+	if got := listCalls.Load(); got != 2 {
+		t.Errorf("got %d list calls, want 2", got)
+	}
+}
+
+func TestPollerReportsMissingInstance(t *testing.T) {
+	server := newInstanceListServer(t)
+	c := NewClient(server.URL, "key", ClientOptions{})
+
+	updates, stop := c.WatchInstance("i-1", time.Millisecond)
+	defer stop()
+
+	if update := receive(t, updates); !IsNotFound(update.Err) {
+		t.Errorf("got error %v, want a not found APIError", update.Err)
+	}
+	if got := server.infoCalls.Load(); got != 1 {
+		t.Errorf("got %d info calls, want 1", got)
+	}
+}
+
+func TestPollerWatcherStopsDuringPoll(t *testing.T) {
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+	var listCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if listCalls.Add(1) == 1 {
+			close(inFlight)
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"instances":[{"id":"i-1","status":"active"},{"id":"i-2","status":"active"}]}`)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key", ClientOptions{})
+	_, stopFirst := c.WatchInstance("i-1", time.Millisecond)
+	updates, stopSecond := c.WatchInstance("i-2", time.Millisecond)
+	defer stopSecond()
+
+	select {
+	case <-inFlight:
+	case <-time.After(pollerTestTimeout):
+		t.Fatal("timed out waiting for the poll to start")
+	}
+	stopFirst()
+	close(release)
+
+	// The remaining watcher still gets the result of the poll
+	if update := receive(t, updates); update.Err != nil {
+		t.Fatalf("unexpected error: %s", update.Err)
+	}
+
+	stopSecond()
+	waitForPollerStopped(t, c)
+}
+
+func TestPollerRestartsAfterExit(t *testing.T) {
+	server := newInstanceListServer(t, "i-1")
+	c := NewClient(server.URL, "key", ClientOptions{})
+
+	updates, stop := c.WatchInstance("i-1", time.Millisecond)
+	receive(t, updates)
+	stop()
+	waitForPollerStopped(t, c)
+
+	updates, stop = c.WatchInstance("i-1", time.Millisecond)
+	defer stop()
+	if update := receive(t, updates); update.Err != nil {
+		t.Fatalf("unexpected error after restart: %s", update.Err)
+	}
+	if got := server.listCalls.Load(); got < 2 {
+		t.Errorf("got %d list calls, want at least 2", got)
+	}
+}
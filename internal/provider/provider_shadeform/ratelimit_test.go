@@ -0,0 +1,145 @@
+package provider_shadeform
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurst(t *testing.T) {
+	tests := []struct {
+		requestsPerSecond float64
+		wantBurst         int
+	}{
+		{0.5, 1},
+		{1, 1},
+		{2.5, 3},
+		{10, 10},
+	}
+
+	for _, tt := range tests {
+		l := newRateLimiter(tt.requestsPerSecond)
+		for i := 0; i < tt.wantBurst; i++ {
+			if wait := l.reserve(); wait != 0 {
+				t.Fatalf("rate %g: request %d waited %s, want a burst of %d", tt.requestsPerSecond, i+1, wait, tt.wantBurst)
+			}
+		}
+
+		wait := l.reserve()
+		if limit := time.Duration(float64(time.Second) / tt.requestsPerSecond); wait <= 0 || wait > limit {
+			t.Errorf("rate %g: request after the burst waited %s, want up to %s", tt.requestsPerSecond, wait, limit)
+		}
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	l := newRateLimiter(0)
+	if l != nil {
+		t.Fatalf("newRateLimiter(0) = %v, want nil", l)
+	}
+	for i := 0; i < 100; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait on a disabled limiter: %s", err)
+		}
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	l := newRateLimiter(0.1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClientRequestRate(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"instances":[]}`)
+	}))
+	defer server.Close()
+
+	// A burst of 10 followed by 5 requests at 10 per second
+	c := NewClient(server.URL, "key", ClientOptions{RequestsPerSecond: 10})
+	start := time.Now()
+	for i := 0; i < 15; i++ {
+		if _, err := c.ListInstances(context.Background()); err != nil {
+			t.Fatalf("ListInstances: %s", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("15 requests took %s, want at least 400ms", elapsed)
+	}
+	if got := calls.Load(); got != 15 {
+		t.Errorf("got %d calls, want 15", got)
+	}
+}
+
+func TestClientConcurrentMutations(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"vol-1"}`)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key", ClientOptions{MaxConcurrentCreates: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.CreateVolume(context.Background(), &CreateVolumeRequest{Name: "test"}); err != nil {
+				t.Errorf("CreateVolume: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got != 2 {
+		t.Errorf("got at most %d mutations in flight, want 2", got)
+	}
+}
+
+func TestSemaphoreAcquireCanceled(t *testing.T) {
+	s := newSemaphore(1)
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Fatalf("first Acquire: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	s.Release()
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Errorf("Acquire after Release: %s", err)
+	}
+}
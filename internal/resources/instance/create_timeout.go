@@ -0,0 +1,166 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+)
+
+// What to do with an instance that is not ready when the create timeout
+// expires or the apply is interrupted.
+const (
+	onCreateTimeoutDelete = "delete"
+	onCreateTimeoutKeep   = "keep"
+	onCreateTimeoutTaint  = "taint"
+)
+
+const (
+	// defaultCreateTimeout applies when neither timeouts.create nor the boot
+	// time of the instance type is known.
+	defaultCreateTimeout = 60 * time.Minute
+
+	// bootTimeoutFactor is how many times its advertised maximum boot time
+	// an instance may take before the create timeout expires, unless
+	// timeouts.create is set.
+	bootTimeoutFactor = 3
+
+	// minBootTimeout keeps the derived create timeout from being cut short
+	// for types that advertise very fast boots.
+	minBootTimeout = 10 * time.Minute
+)
+
+var onCreateTimeoutValues = []string{onCreateTimeoutDelete, onCreateTimeoutKeep, onCreateTimeoutTaint}
+
+// validateOnCreateTimeout checks that on_create_timeout is a known action.
+func validateOnCreateTimeout(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var onCreateTimeout types.String
+	diags.Append(config.GetAttribute(ctx, path.Root("on_create_timeout"), &onCreateTimeout)...)
+	if diags.HasError() || onCreateTimeout.IsNull() || onCreateTimeout.IsUnknown() {
+		return diags
+	}
+
+	switch onCreateTimeout.ValueString() {
+	case onCreateTimeoutDelete, onCreateTimeoutKeep, onCreateTimeoutTaint:
+	default:
+		diags.AddAttributeError(
+			path.Root("on_create_timeout"),
+			"Invalid On Create Timeout",
+			fmt.Sprintf("on_create_timeout must be one of %q, got %q.", onCreateTimeoutValues, onCreateTimeout.ValueString()),
+		)
+	}
+
+	return diags
+}
+
+// resolveCreateTimeout returns timeouts.create, or when it is not set, a
+// multiple of the maximum boot time of the instance type.
+func resolveCreateTimeout(ctx context.Context, plan *InstanceResourceModel, bootTime *provider_shadeform.BootTime) (time.Duration, diag.Diagnostics) {
+	// Zero stands for a create timeout that is not configured
+	timeout, diags := plan.Timeouts.Create(ctx, 0)
+	if diags.HasError() || timeout != 0 {
+		return timeout, diags
+	}
+
+	if bootTime == nil || bootTime.MaxBootInSec <= 0 {
+		return defaultCreateTimeout, diags
+	}
+	return max(bootTimeoutFactor*time.Duration(bootTime.MaxBootInSec)*time.Second, minBootTimeout), diags
+}
+
+// warnSlowBoot adds a warning when an instance took longer to boot than its
+// type advertises.
+func warnSlowBoot(diags *diag.Diagnostics, instanceID string, elapsed time.Duration, bootTime *provider_shadeform.BootTime) {
+	if bootTime == nil || bootTime.MaxBootInSec <= 0 {
+		return
+	}
+
+	maxBoot := time.Duration(bootTime.MaxBootInSec) * time.Second
+	if elapsed <= maxBoot {
+		return
+	}
+
+	diags.AddWarning(
+		"Instance boot took longer than expected",
+		fmt.Sprintf("Instance %s has been booting for %s, longer than the advertised maximum boot time of %s for its type.", instanceID, elapsed.Round(time.Second), maxBoot),
+	)
+}
+
+// handleCreateTimeout applies on_create_timeout to an instance that was not
+// ready before ctx ended. ctx is done by now, so API calls get their own
+// budget.
+func (r *InstanceResource) handleCreateTimeout(ctx context.Context, plan *InstanceResourceModel, resp *resource.CreateResponse) {
+	instanceID := plan.Id.ValueString()
+
+	reason := "timed out"
+	if errors.Is(ctx.Err(), context.Canceled) {
+		reason = "was interrupted"
+	}
+
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+
+	action := plan.OnCreateTimeout.ValueString()
+	if action == "" {
+		action = onCreateTimeoutDelete
+	}
+
+	if action == onCreateTimeoutDelete {
+		tflog.Warn(ctx, fmt.Sprintf("Instance creation %s, attempting to clean up instance %s", reason, instanceID))
+
+		if err := r.client.DeleteInstance(cleanupCtx, instanceID); err != nil {
+			resp.Diagnostics.AddError(
+				"Instance creation failed and cleanup failed",
+				fmt.Sprintf("Instance %s creation %s and could not be deleted: %s. Manual cleanup may be required.", instanceID, reason, err.Error()),
+			)
+			return
+		}
+
+		// If deletion succeeds, there is nothing left to track
+		resp.State.RemoveResource(ctx)
+		resp.Diagnostics.AddError(
+			fmt.Sprintf("Instance creation %s", reason),
+			fmt.Sprintf("Instance %s creation %s and was automatically deleted. Please try again or check your configuration.", instanceID, reason),
+		)
+		return
+	}
+
+	// Record whatever the instance looks like now, the state saved right
+	// after create is kept if it can't be read
+	instance, err := r.client.GetInstance(cleanupCtx, instanceID)
+	if err != nil {
+		tflog.Warn(ctx, fmt.Sprintf("could not read instance %s after creation %s: %s", instanceID, reason, err))
+	} else {
+		sshKeyId := plan.SshKeyId
+		resp.Diagnostics.Append(plan.flatten(ctx, instance, r.client.DefaultTags())...)
+		if !sshKeyId.IsNull() && !sshKeyId.IsUnknown() {
+			plan.SshKeyId = sshKeyId
+		}
+		resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+	}
+
+	// An error leaves the resource tainted, so the next apply replaces it
+	if action == onCreateTimeoutTaint {
+		resp.Diagnostics.AddError(
+			fmt.Sprintf("Instance creation %s", reason),
+			fmt.Sprintf("Instance %s creation %s before it became active. It was kept and marked as tainted, so the next apply replaces it.", instanceID, reason),
+		)
+		return
+	}
+
+	resp.Diagnostics.AddWarning(
+		fmt.Sprintf("Instance creation %s", reason),
+		fmt.Sprintf("Instance %s creation %s before it became active. It was kept with status %q, the next refresh picks up its progress.", instanceID, reason, plan.Status.ValueString()),
+	)
+}
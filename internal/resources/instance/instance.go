@@ -3,23 +3,40 @@ package instance
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
 	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/shadeform/terraform-provider-shadeform/internal/convert"
 	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+	"github.com/shadeform/terraform-provider-shadeform/internal/resources/tags"
 )
 
+// cleanupTimeout bounds API calls made after the create deadline has passed.
+const cleanupTimeout = 2 * time.Minute
+
 var (
 	_ resource.Resource                = &InstanceResource{}
 	_ resource.ResourceWithConfigure   = &InstanceResource{}
 	_ resource.ResourceWithImportState = &InstanceResource{}
+	_ resource.ResourceWithModifyPlan  = &InstanceResource{}
+
+	_ resource.ResourceWithValidateConfig = &InstanceResource{}
 )
 
 type InstanceResource struct {
@@ -27,26 +44,39 @@ type InstanceResource struct {
 }
 
 type InstanceResourceModel struct {
-	Id                types.String   `tfsdk:"id"`
-	Cloud             types.String   `tfsdk:"cloud"`
-	Region            types.String   `tfsdk:"region"`
-	ShadeInstanceType types.String   `tfsdk:"shade_instance_type"`
-	ShadeCloud        types.Bool     `tfsdk:"shade_cloud"`
-	Name              types.String   `tfsdk:"name"`
-	Os                types.String   `tfsdk:"os"`
-	SshKeyId          types.String   `tfsdk:"ssh_key_id"`
-	TemplateId        types.String   `tfsdk:"template_id"`
-	VolumeIds         types.List     `tfsdk:"volume_ids"`
-	CloudInstanceType types.String   `tfsdk:"cloud_instance_type"`
-	CloudAssignedID   types.String   `tfsdk:"cloud_assigned_id"`
-	IP                types.String   `tfsdk:"ip"`
-	SshUser           types.String   `tfsdk:"ssh_user"`
-	SshPort           types.Int64    `tfsdk:"ssh_port"`
-	Status            types.String   `tfsdk:"status"`
-	CostEstimate      types.String   `tfsdk:"cost_estimate"`
-	HourlyPrice       types.String   `tfsdk:"hourly_price"`
-	CreatedAt         types.String   `tfsdk:"created_at"`
-	Timeouts          timeouts.Value `tfsdk:"timeouts"`
+	Id                types.String `tfsdk:"id"`
+	Cloud             types.String `tfsdk:"cloud"`
+	Region            types.String `tfsdk:"region"`
+	ShadeInstanceType types.String `tfsdk:"shade_instance_type"`
+	ShadeCloud        types.Bool   `tfsdk:"shade_cloud"`
+	Name              types.String `tfsdk:"name"`
+	Os                types.String `tfsdk:"os"`
+	SshKeyId          types.String `tfsdk:"ssh_key_id"`
+	TemplateId        types.String `tfsdk:"template_id"`
+	VolumeIds         types.List   `tfsdk:"volume_ids"`
+	CloudInstanceType types.String `tfsdk:"cloud_instance_type"`
+	CloudAssignedID   types.String `tfsdk:"cloud_assigned_id"`
+	IP                types.String `tfsdk:"ip"`
+	SshUser           types.String `tfsdk:"ssh_user"`
+	SshPort           types.Int64  `tfsdk:"ssh_port"`
+	Status            types.String `tfsdk:"status"`
+	CostEstimate      types.String `tfsdk:"cost_estimate"`
+	HourlyPrice       types.String `tfsdk:"hourly_price"`
+	CreatedAt         types.String `tfsdk:"created_at"`
+
+	Tags                types.Set      `tfsdk:"tags"`
+	TagsAll             types.Set      `tfsdk:"tags_all"`
+	Env                 types.Map      `tfsdk:"env"`
+	StartupScript       types.String   `tfsdk:"startup_script"`
+	StartupScriptSha256 types.String   `tfsdk:"startup_script_sha256"`
+	LaunchConfiguration types.Object   `tfsdk:"launch_configuration"`
+	AutoDelete          types.Object   `tfsdk:"auto_delete"`
+	TTL                 types.String   `tfsdk:"ttl"`
+	ExpiresAt           types.String   `tfsdk:"expires_at"`
+	OnCreateTimeout     types.String   `tfsdk:"on_create_timeout"`
+	Polling             types.Object   `tfsdk:"polling"`
+	Alert               types.Object   `tfsdk:"alert"`
+	Timeouts            timeouts.Value `tfsdk:"timeouts"`
 }
 
 func NewInstanceResource() resource.Resource {
@@ -64,65 +94,111 @@ func (r *InstanceResource) Schema(ctx context.Context, _ resource.SchemaRequest,
 			"id": schema.StringAttribute{
 				Description: "The unique identifier for the instance.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"cloud": schema.StringAttribute{
-				Description: "The cloud provider.",
+				Description: "The cloud provider. Changing it replaces the instance.",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"region": schema.StringAttribute{
-				Description: "The region where the instance will be deployed.",
+				Description: "The region where the instance will be deployed. Changing it replaces the instance.",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"shade_instance_type": schema.StringAttribute{
-				Description: "The Shadeform standardized instance type.",
+				Description: "The Shadeform standardized instance type. Changing it replaces the instance.",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"shade_cloud": schema.BoolAttribute{
-				Description: "Whether to use Shade Cloud or linked cloud account. This is usually true.",
+				Description: "Whether to use Shade Cloud or linked cloud account. This is usually true. Changing it replaces the instance.",
 				Optional:    true,
 				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+					boolplanmodifier.RequiresReplaceIfConfigured(),
+				},
 			},
 			"name": schema.StringAttribute{
 				Description: "The name of the instance.",
 				Required:    true,
 			},
 			"os": schema.StringAttribute{
-				Description: "The operating system of the instance.",
+				Description: "The operating system of the instance. Defaults to the default OS of the cloud provider. Changing it replaces the instance.",
 				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
 			},
 			"ssh_key_id": schema.StringAttribute{
-				Description: "The ID of the SSH key to use for this instance.",
+				Description: "The ID of the SSH key to use for this instance. Changing it replaces the instance.",
 				Optional:    true,
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
 			},
 			"template_id": schema.StringAttribute{
-				Description: "The ID of the template to use for this instance.",
+				Description: "The ID of the template to use for this instance. Changing it replaces the instance.",
 				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"volume_ids": schema.ListAttribute{
 				ElementType: types.StringType,
-				Description: "List of volume IDs to be mounted. Currently only supports 1 volume at a time.",
+				Description: "List of volume IDs to be mounted. Currently only supports 1 volume at a time. Changing it replaces the instance.",
 				Optional:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
 			},
 			"cloud_instance_type": schema.StringAttribute{
 				Description: "The type of the instance in the cloud provider.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"cloud_assigned_id": schema.StringAttribute{
 				Description: "The ID of the instance in the cloud provider.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"ip": schema.StringAttribute{
 				Description: "The IP address of the instance.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"ssh_user": schema.StringAttribute{
 				Description: "The user to use for SSH access to the instance.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"ssh_port": schema.Int64Attribute{
 				Description: "The port to use for SSH access to the instance.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
 			"status": schema.StringAttribute{
 				Description: "The status of the instance.",
@@ -135,20 +211,131 @@ func (r *InstanceResource) Schema(ctx context.Context, _ resource.SchemaRequest,
 			"hourly_price": schema.StringAttribute{
 				Description: "The hourly price of the instance.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"created_at": schema.StringAttribute{
 				Description: "The date and time the instance was created.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"tags": schema.SetAttribute{
+				ElementType: types.StringType,
+				Description: "Tags attached to the instance. Can be changed without replacing the instance.",
+				Optional:    true,
+			},
+			"tags_all": schema.SetAttribute{
+				ElementType: types.StringType,
+				Description: "All tags attached to the instance, including the provider default_tags.",
+				Computed:    true,
+			},
+			"env": schema.MapAttribute{
+				ElementType: types.StringType,
+				Description: "Environment variables set on the instance. Values are kept as configured rather than read back from the API. Changing them replaces the instance.",
+				Optional:    true,
+				Sensitive:   true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"startup_script": schema.StringAttribute{
+				Description: "A shell script run once the instance has booted, for example from file(). The script is not stored in state, only its SHA-256 in startup_script_sha256. Changing it replaces the instance. Requires Terraform 1.11 or later.",
+				Optional:    true,
+				WriteOnly:   true,
+			},
+			"startup_script_sha256": schema.StringAttribute{
+				Description: "The SHA-256 of startup_script.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					startupScriptHashModifier{},
+				},
+			},
+			"ttl": schema.StringAttribute{
+				Description: "How long the instance may live, as a duration such as \"72h\". It is converted into expires_at when the instance is created, and the instance is deleted automatically at that time. Increasing it moves expires_at later without replacing the instance. Conflicts with auto_delete.date_threshold.",
+				Optional:    true,
+			},
+			"on_create_timeout": schema.StringAttribute{
+				Description: "What to do with an instance that is not active when the create timeout expires or the apply is interrupted: \"delete\" it, \"keep\" it in state as it is, or keep it and \"taint\" it so the next apply replaces it. Defaults to \"delete\".",
+				Optional:    true,
+			},
+			"expires_at": schema.StringAttribute{
+				Description: "The date and time, in RFC3339 format, at which the instance is deleted because of ttl.",
+				Computed:    true,
 			},
 		},
 		Blocks: map[string]schema.Block{
+			"launch_configuration": launchConfigurationBlock(),
+			"auto_delete":          thresholdsBlock("Delete the instance automatically once a threshold is reached. Can be changed without replacing the instance.", "deleted"),
+			"polling":              pollingBlock(),
+			"alert":                thresholdsBlock("Send an alert once a threshold is reached. Can be changed without replacing the instance.", "alerted on"),
 			"timeouts": timeouts.Block(ctx, timeouts.Opts{
 				Create: true,
+				Delete: true,
 			}),
 		},
 	}
 }
 
+// ValidateConfig checks the parts of the configuration the schema cannot
+// express.
+func (r *InstanceResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(validateThresholds(ctx, req.Config, "auto_delete")...)
+	resp.Diagnostics.Append(validateThresholds(ctx, req.Config, "alert")...)
+	resp.Diagnostics.Append(validateTTL(ctx, req.Config)...)
+	resp.Diagnostics.Append(validateOnCreateTimeout(ctx, req.Config)...)
+	resp.Diagnostics.Append(validatePolling(ctx, req.Config)...)
+
+	var launchConfig types.Object
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("launch_configuration"), &launchConfig)...)
+	var startupScript types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("startup_script"), &startupScript)...)
+	if resp.Diagnostics.HasError() || launchConfig.IsNull() || launchConfig.IsUnknown() {
+		return
+	}
+
+	// The API runs either a container or a script, not both
+	if !startupScript.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("startup_script"),
+			"Conflicting Launch Configuration",
+			"startup_script cannot be combined with a launch_configuration block.",
+		)
+		return
+	}
+
+	var image types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("launch_configuration").AtName("docker_configuration").AtName("image"), &image)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if image.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("launch_configuration").AtName("docker_configuration").AtName("image"),
+			"Missing Docker Image",
+			"A launch_configuration block requires a docker_configuration block with an image.",
+		)
+	}
+}
+
+// ModifyPlan plans tags_all as the union of tags and the provider default_tags,
+// and expires_at from ttl.
+func (r *InstanceResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan when the resource is being destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	planExpiresAt(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tags.PlanAll(ctx, req, resp, r.client)
+}
+
 // Configure adds the provider configured client to the resource.
 func (r *InstanceResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
@@ -180,42 +367,83 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 	}
 
 	// Build request body
-	requestBody := map[string]interface{}{
-		"cloud":               plan.Cloud.ValueString(),
-		"region":              plan.Region.ValueString(),
-		"shade_instance_type": plan.ShadeInstanceType.ValueString(),
-		"name":                plan.Name.ValueString(),
+	requestBody := &provider_shadeform.CreateInstanceRequest{
+		Cloud:             plan.Cloud.ValueString(),
+		Region:            plan.Region.ValueString(),
+		ShadeInstanceType: plan.ShadeInstanceType.ValueString(),
+		Name:              plan.Name.ValueString(),
+		Os:                plan.Os.ValueString(),
+		TemplateID:        plan.TemplateId.ValueString(),
+		SshKeyID:          plan.SshKeyId.ValueString(),
 	}
 
-	if !plan.Os.IsNull() && !plan.Os.IsUnknown() {
-		requestBody["os"] = plan.Os.ValueString()
+	if !plan.ShadeCloud.IsNull() && !plan.ShadeCloud.IsUnknown() {
+		requestBody.ShadeCloud = plan.ShadeCloud.ValueBoolPointer()
 	}
 
-	if !plan.TemplateId.IsNull() && !plan.TemplateId.IsUnknown() {
-		requestBody["template_id"] = plan.TemplateId.ValueString()
+	// Add volume_ids if specified
+	if !plan.VolumeIds.IsNull() && !plan.VolumeIds.IsUnknown() {
+		diags := plan.VolumeIds.ElementsAs(ctx, &requestBody.VolumeIDs, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
-	if !plan.SshKeyId.IsNull() && !plan.SshKeyId.IsUnknown() {
-		requestBody["ssh_key_id"] = plan.SshKeyId.ValueString()
+	// Add tags, including the provider default tags, if any
+	tagsAll, diags := tags.Merge(ctx, plan.Tags, r.client.DefaultTags())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
-
-	if !plan.ShadeCloud.IsNull() && !plan.ShadeCloud.IsUnknown() {
-		requestBody["shade_cloud"] = plan.ShadeCloud.ValueBool()
+	if !tagsAll.IsNull() {
+		diags = tagsAll.ElementsAs(ctx, &requestBody.Tags, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
-	// Add volume_ids if specified
-	if !plan.VolumeIds.IsNull() && !plan.VolumeIds.IsUnknown() {
-		var volumeIds []string
-		diags := plan.VolumeIds.ElementsAs(ctx, &volumeIds, false)
+	// Add envs if specified, sorted so the payload is stable
+	if !plan.Env.IsNull() && !plan.Env.IsUnknown() {
+		var env map[string]string
+		diags := plan.Env.ElementsAs(ctx, &env, false)
 		resp.Diagnostics.Append(diags...)
 		if resp.Diagnostics.HasError() {
 			return
 		}
-		requestBody["volume_ids"] = volumeIds
+		for _, name := range slices.Sorted(maps.Keys(env)) {
+			requestBody.Envs = append(requestBody.Envs, provider_shadeform.EnvVar{Name: name, Value: env[name]})
+		}
+	}
+
+	requestBody.LaunchConfiguration, diags = expandLaunchConfiguration(ctx, plan.LaunchConfiguration)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.resolveExpiresAt()
+	requestBody.AutoDelete, diags = plan.autoDeleteThresholds(ctx)
+	resp.Diagnostics.Append(diags...)
+	requestBody.Alert, diags = expandThresholds(ctx, plan.Alert)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// startup_script is write-only, so it is only available from the config
+	var startupScript types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("startup_script"), &startupScript)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !startupScript.IsNull() && !startupScript.IsUnknown() {
+		requestBody.LaunchConfiguration = scriptLaunchConfiguration(startupScript.ValueString())
 	}
 
 	// Create instance
-	result, err := r.client.CreateInstance(requestBody)
+	result, err := r.client.CreateInstance(ctx, requestBody)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error creating instance",
@@ -224,9 +452,8 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	// Extract instance ID from response
-	instanceID, ok := result["id"].(string)
-	if !ok {
+	instanceID := result.ID
+	if instanceID == "" {
 		resp.Diagnostics.AddError(
 			"Error creating instance",
 			"Could not extract instance ID from response",
@@ -234,9 +461,29 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	const defaultCreateTimeout = 60 * time.Minute
+	bootStart := time.Now()
+
+	// Save the instance as requested before waiting for it, so that any
+	// failure from here on leaves a tainted resource rather than an orphan
+	plan.Id = types.StringValue(instanceID)
+	resp.Diagnostics.Append(plan.flatten(ctx, requestedInstance(instanceID, result, requestBody), r.client.DefaultTags())...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	polling, diags := expandPolling(ctx, plan.Polling)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	bootTime := lookupBootTime(ctx, r.client, plan.Cloud.ValueString(), plan.Region.ValueString(), plan.ShadeInstanceType.ValueString())
 
-	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	createTimeout, diags := resolveCreateTimeout(ctx, &plan, bootTime)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
@@ -245,40 +492,25 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 	ctx, cancel := context.WithTimeout(ctx, createTimeout)
 	defer cancel()
 
-	if err := pollInstanceStatus(ctx, r.client, plan.Name.ValueString(), instanceID, 15*time.Second); err != nil {
-		// If polling failed due to timeout, attempt to clean up the instance
-		if ctx.Err() == context.DeadlineExceeded {
-			tflog.Warn(ctx, fmt.Sprintf("Instance creation timed out, attempting to clean up instance %s", instanceID))
-
-			// Attempt to delete the instance
-			deleteErr := r.client.DeleteInstance(instanceID)
-			if deleteErr != nil {
-				// If deletion fails, return a comprehensive error
-				resp.Diagnostics.AddError(
-					"Instance creation failed and cleanup failed",
-					fmt.Sprintf("Instance %s creation timed out and could not be deleted: %s. Manual cleanup may be required.", instanceID, deleteErr.Error()),
-				)
-				return
-			}
-
-			// If deletion succeeds, return a timeout error
-			resp.Diagnostics.AddError(
-				"Instance creation timed out",
-				fmt.Sprintf("Instance %s creation timed out and was automatically deleted. Please try again or check your configuration.", instanceID),
-			)
+	err = pollInstanceStatus(ctx, r.client, plan.Name.ValueString(), instanceID, polling, bootTime)
+	warnSlowBoot(&resp.Diagnostics, instanceID, time.Since(bootStart), bootTime)
+	if err != nil {
+		// The create timeout expired or the apply was interrupted
+		if ctx.Err() != nil {
+			r.handleCreateTimeout(ctx, &plan, resp)
 			return
 		}
 
 		// For other errors (like instance in error state), just return the original error
 		resp.Diagnostics.AddError(
 			"Instance not ready",
-			fmt.Sprintf("failed waiting for %s to become active: %s", instanceID, err),
+			fmt.Sprintf("failed waiting for %s to become %s: %s", instanceID, polling.targetStatus, err),
 		)
 		return
 	}
 
 	// Now fetch the full instance info to populate all computed fields
-	instanceInfo, err := r.client.GetInstance(instanceID)
+	instanceInfo, err := r.client.GetInstance(ctx, instanceID)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error reading instance after create",
@@ -287,111 +519,17 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	// Set all fields from the API response
-	plan.Id = types.StringValue(instanceID)
-	if cloud, ok := instanceInfo["cloud"].(string); ok {
-		plan.Cloud = types.StringValue(cloud)
-	}
-	if region, ok := instanceInfo["region"].(string); ok {
-		plan.Region = types.StringValue(region)
-	}
-	if shadeInstanceType, ok := instanceInfo["shade_instance_type"].(string); ok {
-		plan.ShadeInstanceType = types.StringValue(shadeInstanceType)
-	}
-	if shadeCloud, ok := instanceInfo["shade_cloud"].(bool); ok {
-		plan.ShadeCloud = types.BoolValue(shadeCloud)
-	}
-	if name, ok := instanceInfo["name"].(string); ok {
-		plan.Name = types.StringValue(name)
-	}
-
-	// Handle optional fields that might be returned by the API
-	if os, ok := instanceInfo["os"].(string); ok {
-		plan.Os = types.StringValue(os)
-	} else {
-		plan.Os = types.StringNull()
-	}
-
-	if templateId, ok := instanceInfo["template_id"].(string); ok {
-		plan.TemplateId = types.StringValue(templateId)
-	} else {
-		plan.TemplateId = types.StringNull()
-	}
-
 	// For ssh_key_id, preserve the original plan value if it was set
-	// Only override if it wasn't in the original plan
-	if !plan.SshKeyId.IsNull() && !plan.SshKeyId.IsUnknown() {
-		// Keep the original plan value
-	} else if sshKeyId, ok := instanceInfo["ssh_key_id"].(string); ok {
-		plan.SshKeyId = types.StringValue(sshKeyId)
-	} else {
-		plan.SshKeyId = types.StringNull()
-	}
-	if cloudInstanceType, ok := instanceInfo["cloud_instance_type"].(string); ok {
-		plan.CloudInstanceType = types.StringValue(cloudInstanceType)
-	} else {
-		plan.CloudInstanceType = types.StringNull()
-	}
-	if cloudAssignedId, ok := instanceInfo["cloud_assigned_id"].(string); ok {
-		plan.CloudAssignedID = types.StringValue(cloudAssignedId)
-	} else {
-		plan.CloudAssignedID = types.StringNull()
-	}
-	if ip, ok := instanceInfo["ip"].(string); ok {
-		plan.IP = types.StringValue(ip)
-	} else {
-		plan.IP = types.StringNull()
-	}
-	if sshUser, ok := instanceInfo["ssh_user"].(string); ok {
-		plan.SshUser = types.StringValue(sshUser)
-	} else {
-		plan.SshUser = types.StringNull()
-	}
-	if sshPort, ok := instanceInfo["ssh_port"].(int64); ok {
-		plan.SshPort = types.Int64Value(sshPort)
-	} else {
-		plan.SshPort = types.Int64Null()
-	}
-	if status, ok := instanceInfo["status"].(string); ok {
-		plan.Status = types.StringValue(status)
-	} else {
-		plan.Status = types.StringNull()
-	}
-	if costEstimate, ok := instanceInfo["cost_estimate"].(string); ok {
-		plan.CostEstimate = types.StringValue(costEstimate)
-	} else {
-		plan.CostEstimate = types.StringNull()
-	}
-	if hourlyPrice, ok := instanceInfo["hourly_price"].(string); ok {
-		plan.HourlyPrice = types.StringValue(hourlyPrice)
-	} else {
-		plan.HourlyPrice = types.StringNull()
-	}
-	if createdAt, ok := instanceInfo["created_at"].(string); ok {
-		plan.CreatedAt = types.StringValue(createdAt)
-	} else {
-		plan.CreatedAt = types.StringNull()
+	sshKeyId := plan.SshKeyId
+
+	// Set all fields from the API response
+	resp.Diagnostics.Append(plan.flatten(ctx, instanceInfo, r.client.DefaultTags())...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	// Handle volume_ids - it's a list in the API response
-	if volumeIdsRaw, ok := instanceInfo["volume_ids"]; ok && volumeIdsRaw != nil {
-		if volumeIdsArray, ok := volumeIdsRaw.([]interface{}); ok {
-			var volumeIds []attr.Value
-			for _, v := range volumeIdsArray {
-				if vStr, ok := v.(string); ok {
-					volumeIds = append(volumeIds, types.StringValue(vStr))
-				}
-			}
-			if len(volumeIds) > 0 {
-				plan.VolumeIds = types.ListValueMust(types.StringType, volumeIds)
-			} else {
-				plan.VolumeIds = types.ListNull(types.StringType)
-			}
-		} else {
-			plan.VolumeIds = types.ListNull(types.StringType)
-		}
-	} else {
-		plan.VolumeIds = types.ListNull(types.StringType)
+	if !sshKeyId.IsNull() && !sshKeyId.IsUnknown() {
+		plan.SshKeyId = sshKeyId
 	}
 
 	// Set state
@@ -410,7 +548,13 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 	}
 
 	// Get instance from API
-	result, err := r.client.GetInstance(state.Id.ValueString())
+	result, err := r.client.GetInstance(ctx, state.Id.ValueString())
+	if provider_shadeform.IsNotFound(err) || (err == nil && result.Status == provider_shadeform.InstanceStatusDeleted) {
+		// The instance is gone, so let the next plan recreate it
+		tflog.Warn(ctx, fmt.Sprintf("instance %s no longer exists, removing from state", state.Id.ValueString()))
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error reading instance",
@@ -420,105 +564,9 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 	}
 
 	// Update state with API response
-	if cloud, ok := result["cloud"].(string); ok {
-		state.Cloud = types.StringValue(cloud)
-	}
-	if region, ok := result["region"].(string); ok {
-		state.Region = types.StringValue(region)
-	}
-	if shadeInstanceType, ok := result["shade_instance_type"].(string); ok {
-		state.ShadeInstanceType = types.StringValue(shadeInstanceType)
-	}
-	if shadeCloud, ok := result["shade_cloud"].(bool); ok {
-		state.ShadeCloud = types.BoolValue(shadeCloud)
-	}
-	if name, ok := result["name"].(string); ok {
-		state.Name = types.StringValue(name)
-	}
-
-	// Handle optional fields that might be returned by the API
-	if os, ok := result["os"].(string); ok {
-		state.Os = types.StringValue(os)
-	} else {
-		state.Os = types.StringNull()
-	}
-
-	if templateId, ok := result["template_id"].(string); ok {
-		state.TemplateId = types.StringValue(templateId)
-	} else {
-		state.TemplateId = types.StringNull()
-	}
-
-	if sshKeyId, ok := result["ssh_key_id"].(string); ok {
-		state.SshKeyId = types.StringValue(sshKeyId)
-	} else {
-		state.SshKeyId = types.StringNull()
-	}
-	if cloudInstanceType, ok := result["cloud_instance_type"].(string); ok {
-		state.CloudInstanceType = types.StringValue(cloudInstanceType)
-	} else {
-		state.CloudInstanceType = types.StringNull()
-	}
-	if cloudAssignedId, ok := result["cloud_assigned_id"].(string); ok {
-		state.CloudAssignedID = types.StringValue(cloudAssignedId)
-	} else {
-		state.CloudAssignedID = types.StringNull()
-	}
-	if ip, ok := result["ip"].(string); ok {
-		state.IP = types.StringValue(ip)
-	} else {
-		state.IP = types.StringNull()
-	}
-	if sshUser, ok := result["ssh_user"].(string); ok {
-		state.SshUser = types.StringValue(sshUser)
-	} else {
-		state.SshUser = types.StringNull()
-	}
-	if sshPort, ok := result["ssh_port"].(int64); ok {
-		state.SshPort = types.Int64Value(sshPort)
-	} else {
-		state.SshPort = types.Int64Null()
-	}
-	if status, ok := result["status"].(string); ok {
-		state.Status = types.StringValue(status)
-	} else {
-		state.Status = types.StringNull()
-	}
-	if costEstimate, ok := result["cost_estimate"].(string); ok {
-		state.CostEstimate = types.StringValue(costEstimate)
-	} else {
-		state.CostEstimate = types.StringNull()
-	}
-	if hourlyPrice, ok := result["hourly_price"].(string); ok {
-		state.HourlyPrice = types.StringValue(hourlyPrice)
-	} else {
-		state.HourlyPrice = types.StringNull()
-	}
-	if createdAt, ok := result["created_at"].(string); ok {
-		state.CreatedAt = types.StringValue(createdAt)
-	} else {
-		state.CreatedAt = types.StringNull()
-	}
-
-	// Handle volume_ids - it's a list in the API response
-	if volumeIdsRaw, ok := result["volume_ids"]; ok && volumeIdsRaw != nil {
-		if volumeIdsArray, ok := volumeIdsRaw.([]interface{}); ok {
-			var volumeIds []attr.Value
-			for _, v := range volumeIdsArray {
-				if vStr, ok := v.(string); ok {
-					volumeIds = append(volumeIds, types.StringValue(vStr))
-				}
-			}
-			if len(volumeIds) > 0 {
-				state.VolumeIds = types.ListValueMust(types.StringType, volumeIds)
-			} else {
-				state.VolumeIds = types.ListNull(types.StringType)
-			}
-		} else {
-			state.VolumeIds = types.ListNull(types.StringType)
-		}
-	} else {
-		state.VolumeIds = types.ListNull(types.StringType)
+	resp.Diagnostics.Append(state.flatten(ctx, result, r.client.DefaultTags())...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Set state
@@ -540,24 +588,60 @@ func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateReques
 	}
 
 	// Build request body for update
-	requestBody := make(map[string]interface{})
+	requestBody := &provider_shadeform.UpdateInstanceRequest{}
 
 	if !plan.Name.Equal(state.Name) {
-		requestBody["name"] = plan.Name.ValueString()
+		requestBody.Name = plan.Name.ValueStringPointer()
 	}
 
-	// Update instance
-	err := r.client.UpdateInstance(state.Id.ValueString(), requestBody)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error updating instance",
-			"Could not update instance, unexpected error: "+err.Error(),
-		)
+	if !plan.TagsAll.Equal(state.TagsAll) {
+		// An empty list clears the tags
+		tagsAll := []string{}
+		if !plan.TagsAll.IsNull() {
+			diags = plan.TagsAll.ElementsAs(ctx, &tagsAll, false)
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+		requestBody.Tags = &tagsAll
+	}
+
+	// A removed block is sent as empty thresholds to clear them
+	plan.resolveExpiresAt()
+	if !plan.AutoDelete.Equal(state.AutoDelete) || !plan.ExpiresAt.Equal(state.ExpiresAt) {
+		requestBody.AutoDelete, diags = plan.autoDeleteThresholds(ctx)
+		resp.Diagnostics.Append(diags...)
+		if requestBody.AutoDelete == nil {
+			requestBody.AutoDelete = &provider_shadeform.Thresholds{}
+		}
+	}
+	if !plan.Alert.Equal(state.Alert) {
+		requestBody.Alert, diags = expandThresholds(ctx, plan.Alert)
+		resp.Diagnostics.Append(diags...)
+		if requestBody.Alert == nil {
+			requestBody.Alert = &provider_shadeform.Thresholds{}
+		}
+	}
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	// Update instance, unless only provider side settings such as polling
+	// changed
+	if *requestBody != (provider_shadeform.UpdateInstanceRequest{}) {
+		err := r.client.UpdateInstance(ctx, state.Id.ValueString(), requestBody)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error updating instance",
+				"Could not update instance, unexpected error: "+err.Error(),
+			)
+			return
+		}
+	}
+
 	// Fetch the updated instance data to ensure all computed fields are set
-	instanceInfo, err := r.client.GetInstance(state.Id.ValueString())
+	instanceInfo, err := r.client.GetInstance(ctx, state.Id.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error reading instance after update",
@@ -566,56 +650,18 @@ func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
+	// For ssh_key_id, preserve the planned value if it was set, as in Create
+	sshKeyId := plan.SshKeyId
+
 	// Update the plan with the fetched data
 	plan.Id = types.StringValue(state.Id.ValueString())
-	if cloud, ok := instanceInfo["cloud"].(string); ok {
-		plan.Cloud = types.StringValue(cloud)
-	}
-	if region, ok := instanceInfo["region"].(string); ok {
-		plan.Region = types.StringValue(region)
-	}
-	if shadeInstanceType, ok := instanceInfo["shade_instance_type"].(string); ok {
-		plan.ShadeInstanceType = types.StringValue(shadeInstanceType)
-	}
-	if shadeCloud, ok := instanceInfo["shade_cloud"].(bool); ok {
-		plan.ShadeCloud = types.BoolValue(shadeCloud)
-	}
-	if name, ok := instanceInfo["name"].(string); ok {
-		plan.Name = types.StringValue(name)
-	}
-
-	// Handle optional fields that might be returned by the API
-	if os, ok := instanceInfo["os"].(string); ok {
-		plan.Os = types.StringValue(os)
-	} else {
-		plan.Os = types.StringNull()
-	}
-
-	if templateId, ok := instanceInfo["template_id"].(string); ok {
-		plan.TemplateId = types.StringValue(templateId)
-	} else {
-		plan.TemplateId = types.StringNull()
+	resp.Diagnostics.Append(plan.flatten(ctx, instanceInfo, r.client.DefaultTags())...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	// Handle volume_ids - it's a list in the API response
-	if volumeIdsRaw, ok := instanceInfo["volume_ids"]; ok && volumeIdsRaw != nil {
-		if volumeIdsArray, ok := volumeIdsRaw.([]interface{}); ok {
-			var volumeIds []attr.Value
-			for _, v := range volumeIdsArray {
-				if vStr, ok := v.(string); ok {
-					volumeIds = append(volumeIds, types.StringValue(vStr))
-				}
-			}
-			if len(volumeIds) > 0 {
-				plan.VolumeIds = types.ListValueMust(types.StringType, volumeIds)
-			} else {
-				plan.VolumeIds = types.ListNull(types.StringType)
-			}
-		} else {
-			plan.VolumeIds = types.ListNull(types.StringType)
-		}
-	} else {
-		plan.VolumeIds = types.ListNull(types.StringType)
+	if !sshKeyId.IsNull() && !sshKeyId.IsUnknown() {
+		plan.SshKeyId = sshKeyId
 	}
 
 	// Set state
@@ -633,8 +679,22 @@ func (r *InstanceResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
-	// Delete instance
-	err := r.client.DeleteInstance(state.Id.ValueString())
+	const defaultDeleteTimeout = 20 * time.Minute
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Delete instance, an instance that is already gone counts as deleted
+	err := r.client.DeleteInstance(ctx, state.Id.ValueString())
+	if provider_shadeform.IsNotFound(err) {
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error deleting instance",
@@ -642,6 +702,16 @@ func (r *InstanceResource) Delete(ctx context.Context, req resource.DeleteReques
 		)
 		return
 	}
+
+	// Wait for the instance to terminate, so that its volumes are released
+	// before anything else in the configuration is destroyed
+	if err := waitForInstanceDeleted(ctx, r.client, state.Id.ValueString(), 10*time.Second); err != nil {
+		resp.Diagnostics.AddError(
+			"Error waiting for instance deletion",
+			fmt.Sprintf("Instance %s was not deleted: %s", state.Id.ValueString(), err),
+		)
+		return
+	}
 }
 
 // ImportState imports the resource into Terraform state.
@@ -649,36 +719,184 @@ func (r *InstanceResource) ImportState(ctx context.Context, req resource.ImportS
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
 
-// pollInstanceStatus blocks until the instance reaches the wanted status or
-// the ctx deadline is hit.
+// pollInstanceStatus blocks until the instance reaches the target status, a
+// failure status, or the ctx deadline is hit. Status updates come from the
+// provider-wide poller so that concurrent creates share a single list call per
+// tick.
 func pollInstanceStatus(
 	ctx context.Context,
 	c *provider_shadeform.Client,
 	name string,
 	id string,
-	interval time.Duration,
+	settings pollSettings,
+	bootTime *provider_shadeform.BootTime,
 ) error {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	updates, stop := c.WatchInstanceWithBackoff(id, settings.initialInterval, settings.maxInterval)
+	defer stop()
 
+	start := time.Now()
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err() // timeout or user ^C
-		case <-ticker.C:
-			info, err := c.GetInstance(id)
-			if err != nil {
-				return err // API error – abort
+		case update := <-updates:
+			if update.Err != nil {
+				return update.Err // instance is gone – abort
 			}
 
-			status, _ := info["status"].(string)
-			tflog.Debug(ctx, fmt.Sprintf("instance [name: %s, id: %s] status=%s", name, id, status))
+			status := update.Instance.Status
+			tflog.Info(ctx, fmt.Sprintf("instance [name: %s, id: %s] status=%s, %s", name, id, status, bootProgress(time.Since(start), bootTime)))
 
-			if status == "active" {
+			if status == settings.targetStatus {
 				return nil // success
-			} else if status == "error" {
-				return fmt.Errorf("instance %s is in error state", id)
+			} else if slices.Contains(settings.failureStatuses, status) {
+				return fmt.Errorf("instance %s is in %s state", id, status)
 			}
 		}
 	}
 }
+
+// waitForInstanceDeleted blocks until the instance reports deleted or is no
+// longer found, or the ctx deadline is hit.
+func waitForInstanceDeleted(
+	ctx context.Context,
+	c *provider_shadeform.Client,
+	id string,
+	interval time.Duration,
+) error {
+	updates, stop := c.WatchInstance(id, interval)
+	defer stop()
+
+	status := "unknown"
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w, last status %s", ctx.Err(), status)
+		case update := <-updates:
+			if provider_shadeform.IsNotFound(update.Err) {
+				return nil
+			}
+			if update.Err != nil {
+				return update.Err
+			}
+
+			status = update.Instance.Status
+			tflog.Debug(ctx, fmt.Sprintf("waiting for instance %s to be deleted, status=%s", id, status))
+
+			if status == provider_shadeform.InstanceStatusDeleted {
+				return nil
+			}
+		}
+	}
+}
+
+// requestedInstance describes a newly created instance by what was asked for,
+// until the API reports it in full. Blocks are left out, so flatten keeps
+// their planned values.
+func requestedInstance(id string, result *provider_shadeform.CreateInstanceResponse, req *provider_shadeform.CreateInstanceRequest) *provider_shadeform.Instance {
+	return &provider_shadeform.Instance{
+		ID:                id,
+		CloudAssignedID:   result.CloudAssignedID,
+		Cloud:             req.Cloud,
+		Region:            req.Region,
+		ShadeInstanceType: req.ShadeInstanceType,
+		ShadeCloud:        req.ShadeCloud,
+		Name:              req.Name,
+		Os:                req.Os,
+		TemplateID:        req.TemplateID,
+		SshKeyID:          req.SshKeyID,
+		VolumeIDs:         req.VolumeIDs,
+		Tags:              req.Tags,
+	}
+}
+
+// flatten copies the fields of an API instance into the model.
+func (m *InstanceResourceModel) flatten(ctx context.Context, instance *provider_shadeform.Instance, defaultTags []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	// Required fields keep their configured value if the API omits them
+	if instance.Cloud != "" {
+		m.Cloud = types.StringValue(instance.Cloud)
+	}
+	if instance.Region != "" {
+		m.Region = types.StringValue(instance.Region)
+	}
+	if instance.ShadeInstanceType != "" {
+		m.ShadeInstanceType = types.StringValue(instance.ShadeInstanceType)
+	}
+	if instance.Name != "" {
+		m.Name = types.StringValue(instance.Name)
+	}
+	if instance.ShadeCloud != nil {
+		m.ShadeCloud = types.BoolPointerValue(instance.ShadeCloud)
+	} else if m.ShadeCloud.IsUnknown() {
+		m.ShadeCloud = types.BoolNull()
+	}
+
+	// Changing these replaces the instance, and the API does not always
+	// return them, so an empty value keeps what is known already
+	m.Os = stringOrPrior(instance.Os, m.Os)
+	m.TemplateId = stringOrPrior(instance.TemplateID, m.TemplateId)
+	m.SshKeyId = stringOrPrior(instance.SshKeyID, m.SshKeyId)
+	m.CloudInstanceType = convert.StringOrNull(instance.CloudInstanceType)
+	m.CloudAssignedID = convert.StringOrNull(instance.CloudAssignedID)
+	m.IP = convert.StringOrNull(instance.IP)
+	m.SshUser = convert.StringOrNull(instance.SshUser)
+	if instance.SshPort != 0 {
+		m.SshPort = types.Int64Value(instance.SshPort)
+	} else {
+		m.SshPort = types.Int64Null()
+	}
+	m.Status = convert.StringOrNull(instance.Status)
+	m.CostEstimate = convert.StringOrNull(string(instance.CostEstimate))
+	m.HourlyPrice = convert.StringOrNull(string(instance.HourlyPrice))
+	m.CreatedAt = convert.StringOrNull(instance.CreatedAt)
+
+	// volume_ids is null rather than empty when nothing is mounted
+	if len(instance.VolumeIDs) > 0 {
+		volumeIds := make([]attr.Value, 0, len(instance.VolumeIDs))
+		for _, v := range instance.VolumeIDs {
+			volumeIds = append(volumeIds, types.StringValue(v))
+		}
+		m.VolumeIds = types.ListValueMust(types.StringType, volumeIds)
+	} else {
+		m.VolumeIds = types.ListNull(types.StringType)
+	}
+
+	m.Tags, m.TagsAll = tags.Flatten(instance.Tags, m.Tags, m.TagsAll, defaultTags)
+
+	launchConfig, d := flattenLaunchConfiguration(ctx, instance.LaunchConfiguration, m.LaunchConfiguration)
+	diags.Append(d...)
+	m.LaunchConfiguration = launchConfig
+
+	// The auto_delete date belongs to expires_at while a ttl is set
+	apiAutoDelete := instance.AutoDelete
+	if !m.ExpiresAt.IsNull() && !m.ExpiresAt.IsUnknown() && apiAutoDelete != nil && apiAutoDelete.DateThreshold != "" {
+		if !sameInstant(apiAutoDelete.DateThreshold, m.ExpiresAt.ValueString()) {
+			m.ExpiresAt = types.StringValue(apiAutoDelete.DateThreshold)
+		}
+		apiAutoDelete = &provider_shadeform.Thresholds{SpendThreshold: apiAutoDelete.SpendThreshold}
+	}
+	if m.ExpiresAt.IsUnknown() {
+		m.ExpiresAt = types.StringNull()
+	}
+
+	autoDelete, d := flattenThresholds(ctx, apiAutoDelete, m.AutoDelete)
+	diags.Append(d...)
+	m.AutoDelete = autoDelete
+
+	alert, d := flattenThresholds(ctx, instance.Alert, m.Alert)
+	diags.Append(d...)
+	m.Alert = alert
+
+	return diags
+}
+
+// stringOrPrior is convert.StringOrNull, except that prior is kept when the API
+// returns the empty string.
+func stringOrPrior(s string, prior types.String) types.String {
+	if s == "" && !prior.IsUnknown() {
+		return prior
+	}
+	return convert.StringOrNull(s)
+}
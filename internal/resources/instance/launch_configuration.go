@@ -0,0 +1,363 @@
+package instance
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+
+	"github.com/shadeform/terraform-provider-shadeform/internal/convert"
+	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+)
+
+type LaunchConfigurationModel struct {
+	DockerConfiguration types.Object `tfsdk:"docker_configuration"`
+}
+
+type DockerConfigurationModel struct {
+	Image               types.String `tfsdk:"image"`
+	Args                types.String `tfsdk:"args"`
+	SharedMemoryInGb    types.Int64  `tfsdk:"shared_memory_in_gb"`
+	Envs                types.List   `tfsdk:"envs"`
+	PortMappings        types.List   `tfsdk:"port_mappings"`
+	VolumeMounts        types.List   `tfsdk:"volume_mounts"`
+	RegistryCredentials types.Object `tfsdk:"registry_credentials"`
+}
+
+type EnvModel struct {
+	Name  types.String `tfsdk:"name"`
+	Value types.String `tfsdk:"value"`
+}
+
+type PortMappingModel struct {
+	HostPort      types.Int64 `tfsdk:"host_port"`
+	ContainerPort types.Int64 `tfsdk:"container_port"`
+}
+
+type VolumeMountModel struct {
+	HostPath      types.String `tfsdk:"host_path"`
+	ContainerPath types.String `tfsdk:"container_path"`
+}
+
+type RegistryCredentialsModel struct {
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+}
+
+var envAttrTypes = map[string]attr.Type{
+	"name":  types.StringType,
+	"value": types.StringType,
+}
+
+var portMappingAttrTypes = map[string]attr.Type{
+	"host_port":      types.Int64Type,
+	"container_port": types.Int64Type,
+}
+
+var volumeMountAttrTypes = map[string]attr.Type{
+	"host_path":      types.StringType,
+	"container_path": types.StringType,
+}
+
+var registryCredentialsAttrTypes = map[string]attr.Type{
+	"username": types.StringType,
+	"password": types.StringType,
+}
+
+var dockerConfigurationAttrTypes = map[string]attr.Type{
+	"image":                types.StringType,
+	"args":                 types.StringType,
+	"shared_memory_in_gb":  types.Int64Type,
+	"envs":                 types.ListType{ElemType: types.ObjectType{AttrTypes: envAttrTypes}},
+	"port_mappings":        types.ListType{ElemType: types.ObjectType{AttrTypes: portMappingAttrTypes}},
+	"volume_mounts":        types.ListType{ElemType: types.ObjectType{AttrTypes: volumeMountAttrTypes}},
+	"registry_credentials": types.ObjectType{AttrTypes: registryCredentialsAttrTypes},
+}
+
+var launchConfigurationAttrTypes = map[string]attr.Type{
+	"docker_configuration": types.ObjectType{AttrTypes: dockerConfigurationAttrTypes},
+}
+
+// launchConfigurationBlock is the schema of the launch_configuration block.
+// The API cannot change what an instance runs after it is created, so any
+// change replaces the instance.
+func launchConfigurationBlock() schema.Block {
+	return schema.SingleNestedBlock{
+		Description: "What the instance runs once it has booted.",
+		PlanModifiers: []planmodifier.Object{
+			objectplanmodifier.RequiresReplace(),
+		},
+		Blocks: map[string]schema.Block{
+			"docker_configuration": schema.SingleNestedBlock{
+				Description: "Run a Docker container on the instance.",
+				Attributes: map[string]schema.Attribute{
+					"image": schema.StringAttribute{
+						Description: "The Docker image to run.",
+						Optional:    true,
+					},
+					"args": schema.StringAttribute{
+						Description: "The arguments passed to the container.",
+						Optional:    true,
+					},
+					"shared_memory_in_gb": schema.Int64Attribute{
+						Description: "The shared memory of the container in gigabytes.",
+						Optional:    true,
+					},
+				},
+				Blocks: map[string]schema.Block{
+					"envs": schema.ListNestedBlock{
+						Description: "Environment variables set in the container.",
+						NestedObject: schema.NestedBlockObject{
+							Attributes: map[string]schema.Attribute{
+								"name": schema.StringAttribute{
+									Description: "The name of the environment variable.",
+									Required:    true,
+								},
+								"value": schema.StringAttribute{
+									Description: "The value of the environment variable.",
+									Required:    true,
+									Sensitive:   true,
+								},
+							},
+						},
+					},
+					"port_mappings": schema.ListNestedBlock{
+						Description: "Container ports exposed on the instance.",
+						NestedObject: schema.NestedBlockObject{
+							Attributes: map[string]schema.Attribute{
+								"host_port": schema.Int64Attribute{
+									Description: "The port on the instance.",
+									Required:    true,
+								},
+								"container_port": schema.Int64Attribute{
+									Description: "The port in the container.",
+									Required:    true,
+								},
+							},
+						},
+					},
+					"volume_mounts": schema.ListNestedBlock{
+						Description: "Instance paths mounted into the container.",
+						NestedObject: schema.NestedBlockObject{
+							Attributes: map[string]schema.Attribute{
+								"host_path": schema.StringAttribute{
+									Description: "The path on the instance.",
+									Required:    true,
+								},
+								"container_path": schema.StringAttribute{
+									Description: "The path in the container.",
+									Required:    true,
+								},
+							},
+						},
+					},
+					"registry_credentials": schema.SingleNestedBlock{
+						Description: "Credentials used to pull the image from a private registry.",
+						Attributes: map[string]schema.Attribute{
+							"username": schema.StringAttribute{
+								Description: "The registry username.",
+								Optional:    true,
+							},
+							"password": schema.StringAttribute{
+								Description: "The registry password.",
+								Optional:    true,
+								Sensitive:   true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// expandLaunchConfiguration converts the launch_configuration block into its
+// API representation. It returns nil when the block is not set.
+func expandLaunchConfiguration(ctx context.Context, obj types.Object) (*provider_shadeform.LaunchConfiguration, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if obj.IsNull() || obj.IsUnknown() {
+		return nil, diags
+	}
+
+	var launchConfig LaunchConfigurationModel
+	diags.Append(obj.As(ctx, &launchConfig, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() || launchConfig.DockerConfiguration.IsNull() || launchConfig.DockerConfiguration.IsUnknown() {
+		return nil, diags
+	}
+
+	var docker DockerConfigurationModel
+	diags.Append(launchConfig.DockerConfiguration.As(ctx, &docker, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	result := &provider_shadeform.DockerConfiguration{
+		Image:            docker.Image.ValueString(),
+		Args:             docker.Args.ValueString(),
+		SharedMemoryInGB: docker.SharedMemoryInGb.ValueInt64(),
+	}
+
+	var envs []EnvModel
+	diags.Append(docker.Envs.ElementsAs(ctx, &envs, false)...)
+	for _, env := range envs {
+		result.Envs = append(result.Envs, provider_shadeform.EnvVar{
+			Name:  env.Name.ValueString(),
+			Value: env.Value.ValueString(),
+		})
+	}
+
+	var portMappings []PortMappingModel
+	diags.Append(docker.PortMappings.ElementsAs(ctx, &portMappings, false)...)
+	for _, pm := range portMappings {
+		result.PortMappings = append(result.PortMappings, provider_shadeform.PortMapping{
+			HostPort:      pm.HostPort.ValueInt64(),
+			ContainerPort: pm.ContainerPort.ValueInt64(),
+		})
+	}
+
+	var volumeMounts []VolumeMountModel
+	diags.Append(docker.VolumeMounts.ElementsAs(ctx, &volumeMounts, false)...)
+	for _, vm := range volumeMounts {
+		result.VolumeMounts = append(result.VolumeMounts, provider_shadeform.VolumeMount{
+			HostPath:      vm.HostPath.ValueString(),
+			ContainerPath: vm.ContainerPath.ValueString(),
+		})
+	}
+
+	if !docker.RegistryCredentials.IsNull() && !docker.RegistryCredentials.IsUnknown() {
+		var creds RegistryCredentialsModel
+		diags.Append(docker.RegistryCredentials.As(ctx, &creds, basetypes.ObjectAsOptions{})...)
+		result.RegistryCredentials = &provider_shadeform.RegistryCredentials{
+			Username: creds.Username.ValueString(),
+			Password: creds.Password.ValueString(),
+		}
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	return &provider_shadeform.LaunchConfiguration{
+		Type:                provider_shadeform.LaunchConfigurationTypeDocker,
+		DockerConfiguration: result,
+	}, diags
+}
+
+// flattenLaunchConfiguration converts the launch configuration reported by
+// the API into the launch_configuration block. The API does not echo secrets,
+// so the registry password and env values are carried over from prior.
+// Instances the API reports no launch configuration for keep prior as is.
+func flattenLaunchConfiguration(ctx context.Context, launchConfig *provider_shadeform.LaunchConfiguration, prior types.Object) (types.Object, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if launchConfig == nil || launchConfig.DockerConfiguration == nil {
+		if prior.IsUnknown() {
+			return types.ObjectNull(launchConfigurationAttrTypes), diags
+		}
+		return prior, diags
+	}
+	api := launchConfig.DockerConfiguration
+
+	// Pick up the secrets we sent, which the API does not return
+	var priorDocker DockerConfigurationModel
+	priorEnvs := map[string]types.String{}
+	priorPassword := types.StringNull()
+	if !prior.IsNull() && !prior.IsUnknown() {
+		var priorLaunchConfig LaunchConfigurationModel
+		diags.Append(prior.As(ctx, &priorLaunchConfig, basetypes.ObjectAsOptions{})...)
+		if !priorLaunchConfig.DockerConfiguration.IsNull() && !priorLaunchConfig.DockerConfiguration.IsUnknown() {
+			diags.Append(priorLaunchConfig.DockerConfiguration.As(ctx, &priorDocker, basetypes.ObjectAsOptions{})...)
+
+			var envs []EnvModel
+			diags.Append(priorDocker.Envs.ElementsAs(ctx, &envs, false)...)
+			for _, env := range envs {
+				priorEnvs[env.Name.ValueString()] = env.Value
+			}
+
+			if !priorDocker.RegistryCredentials.IsNull() && !priorDocker.RegistryCredentials.IsUnknown() {
+				var creds RegistryCredentialsModel
+				diags.Append(priorDocker.RegistryCredentials.As(ctx, &creds, basetypes.ObjectAsOptions{})...)
+				priorPassword = creds.Password
+			}
+		}
+	}
+	if diags.HasError() {
+		return prior, diags
+	}
+
+	docker := DockerConfigurationModel{
+		Image:               convert.StringOrNull(api.Image),
+		Args:                convert.StringOrNull(api.Args),
+		SharedMemoryInGb:    types.Int64Null(),
+		RegistryCredentials: types.ObjectNull(registryCredentialsAttrTypes),
+	}
+	if api.SharedMemoryInGB != 0 {
+		docker.SharedMemoryInGb = types.Int64Value(api.SharedMemoryInGB)
+	}
+
+	envs := make([]EnvModel, 0, len(api.Envs))
+	for _, env := range api.Envs {
+		value := convert.StringOrNull(env.Value)
+		if priorValue, ok := priorEnvs[env.Name]; ok && env.Value == "" {
+			value = priorValue
+		}
+		envs = append(envs, EnvModel{Name: types.StringValue(env.Name), Value: value})
+	}
+
+	portMappings := make([]PortMappingModel, 0, len(api.PortMappings))
+	for _, pm := range api.PortMappings {
+		portMappings = append(portMappings, PortMappingModel{
+			HostPort:      types.Int64Value(pm.HostPort),
+			ContainerPort: types.Int64Value(pm.ContainerPort),
+		})
+	}
+
+	volumeMounts := make([]VolumeMountModel, 0, len(api.VolumeMounts))
+	for _, vm := range api.VolumeMounts {
+		volumeMounts = append(volumeMounts, VolumeMountModel{
+			HostPath:      types.StringValue(vm.HostPath),
+			ContainerPath: types.StringValue(vm.ContainerPath),
+		})
+	}
+
+	var d diag.Diagnostics
+	docker.Envs, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: envAttrTypes}, envs)
+	diags.Append(d...)
+	docker.PortMappings, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: portMappingAttrTypes}, portMappings)
+	diags.Append(d...)
+	docker.VolumeMounts, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: volumeMountAttrTypes}, volumeMounts)
+	diags.Append(d...)
+
+	if api.RegistryCredentials != nil {
+		password := convert.StringOrNull(api.RegistryCredentials.Password)
+		if api.RegistryCredentials.Password == "" {
+			password = priorPassword
+		}
+		docker.RegistryCredentials, d = types.ObjectValueFrom(ctx, registryCredentialsAttrTypes, RegistryCredentialsModel{
+			Username: convert.StringOrNull(api.RegistryCredentials.Username),
+			Password: password,
+		})
+		diags.Append(d...)
+	} else if !priorDocker.RegistryCredentials.IsNull() && !priorDocker.RegistryCredentials.IsUnknown() {
+		docker.RegistryCredentials = priorDocker.RegistryCredentials
+	}
+
+	dockerObj, d := types.ObjectValueFrom(ctx, dockerConfigurationAttrTypes, docker)
+	diags.Append(d...)
+
+	result, d := types.ObjectValueFrom(ctx, launchConfigurationAttrTypes, LaunchConfigurationModel{
+		DockerConfiguration: dockerObj,
+	})
+	diags.Append(d...)
+	if diags.HasError() {
+		return prior, diags
+	}
+
+	return result, diags
+}
@@ -0,0 +1,212 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+)
+
+const (
+	defaultPollInitialInterval = 15 * time.Second
+	defaultPollMaxInterval     = 1 * time.Minute
+)
+
+var defaultPollFailureStatuses = []string{
+	provider_shadeform.InstanceStatusError,
+	provider_shadeform.InstanceStatusDeleted,
+}
+
+type PollingModel struct {
+	InitialInterval types.String `tfsdk:"initial_interval"`
+	MaxInterval     types.String `tfsdk:"max_interval"`
+	TargetStatus    types.String `tfsdk:"target_status"`
+	FailureStatuses types.List   `tfsdk:"failure_statuses"`
+}
+
+// pollSettings is the polling block with its defaults filled in.
+type pollSettings struct {
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	targetStatus    string
+	failureStatuses []string
+}
+
+func pollingBlock() schema.Block {
+	return schema.SingleNestedBlock{
+		Description: "How the instance is polled while waiting for it to be created.",
+		Attributes: map[string]schema.Attribute{
+			"initial_interval": schema.StringAttribute{
+				Description: "The time between the first status checks, as a duration such as \"15s\". Defaults to 15s.",
+				Optional:    true,
+			},
+			"max_interval": schema.StringAttribute{
+				Description: "The interval doubles after every status check until it reaches this duration. Defaults to 1m.",
+				Optional:    true,
+			},
+			"target_status": schema.StringAttribute{
+				Description: "The status at which the instance is ready. Defaults to \"active\".",
+				Optional:    true,
+			},
+			"failure_statuses": schema.ListAttribute{
+				ElementType: types.StringType,
+				Description: "Statuses at which creating the instance fails right away instead of waiting for the timeout. Defaults to [\"error\", \"deleted\"].",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+// validatePolling checks the durations and statuses of the polling block.
+func validatePolling(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var obj types.Object
+	diags.Append(config.GetAttribute(ctx, path.Root("polling"), &obj)...)
+	if diags.HasError() || obj.IsNull() || obj.IsUnknown() {
+		return diags
+	}
+
+	var polling PollingModel
+	diags.Append(obj.As(ctx, &polling, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return diags
+	}
+
+	initial, ok := validatePollInterval(polling.InitialInterval, "initial_interval", &diags)
+	maximum, ok2 := validatePollInterval(polling.MaxInterval, "max_interval", &diags)
+	if ok && ok2 && maximum < initial {
+		diags.AddAttributeError(
+			path.Root("polling").AtName("max_interval"),
+			"Invalid Polling Interval",
+			"max_interval must not be shorter than initial_interval.",
+		)
+	}
+
+	if polling.TargetStatus.IsUnknown() || polling.FailureStatuses.IsUnknown() {
+		return diags
+	}
+
+	settings, d := expandPolling(ctx, obj)
+	diags.Append(d...)
+	if diags.HasError() {
+		return diags
+	}
+	if slices.Contains(settings.failureStatuses, settings.targetStatus) {
+		diags.AddAttributeError(
+			path.Root("polling").AtName("failure_statuses"),
+			"Conflicting Polling Statuses",
+			fmt.Sprintf("failure_statuses must not contain the target_status %q.", settings.targetStatus),
+		)
+	}
+
+	return diags
+}
+
+// validatePollInterval checks a single interval of the polling block. It
+// reports false when the interval is not set or not valid.
+func validatePollInterval(value types.String, name string, diags *diag.Diagnostics) (time.Duration, bool) {
+	if value.IsNull() || value.IsUnknown() {
+		return 0, false
+	}
+
+	d, err := time.ParseDuration(value.ValueString())
+	if err != nil || d <= 0 {
+		diags.AddAttributeError(
+			path.Root("polling").AtName(name),
+			"Invalid Polling Interval",
+			fmt.Sprintf("%s must be a positive duration such as \"15s\" or \"1m\".", name),
+		)
+		return 0, false
+	}
+	return d, true
+}
+
+// expandPolling reads the polling block, falling back to the defaults for
+// anything not set. ValidateConfig has already checked the durations.
+func expandPolling(ctx context.Context, obj types.Object) (pollSettings, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	settings := pollSettings{
+		initialInterval: defaultPollInitialInterval,
+		maxInterval:     defaultPollMaxInterval,
+		targetStatus:    provider_shadeform.InstanceStatusActive,
+		failureStatuses: defaultPollFailureStatuses,
+	}
+	if obj.IsNull() || obj.IsUnknown() {
+		return settings, diags
+	}
+
+	var polling PollingModel
+	diags.Append(obj.As(ctx, &polling, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return settings, diags
+	}
+
+	// A single configured interval moves the default of the other one out of
+	// its way
+	initial, initialErr := time.ParseDuration(polling.InitialInterval.ValueString())
+	maximum, maxErr := time.ParseDuration(polling.MaxInterval.ValueString())
+	switch {
+	case initialErr == nil && maxErr == nil:
+		settings.initialInterval, settings.maxInterval = initial, maximum
+	case initialErr == nil:
+		settings.initialInterval, settings.maxInterval = initial, max(defaultPollMaxInterval, initial)
+	case maxErr == nil:
+		settings.initialInterval, settings.maxInterval = min(defaultPollInitialInterval, maximum), maximum
+	}
+	if !polling.TargetStatus.IsNull() && !polling.TargetStatus.IsUnknown() {
+		settings.targetStatus = polling.TargetStatus.ValueString()
+	}
+	if !polling.FailureStatuses.IsNull() && !polling.FailureStatuses.IsUnknown() {
+		settings.failureStatuses = nil
+		diags.Append(polling.FailureStatuses.ElementsAs(ctx, &settings.failureStatuses, false)...)
+	}
+
+	return settings, diags
+}
+
+// lookupBootTime returns the advertised boot time of an instance type, or
+// nil if the API does not know it.
+func lookupBootTime(ctx context.Context, c *provider_shadeform.Client, cloud, region, shadeInstanceType string) *provider_shadeform.BootTime {
+	instanceTypes, err := c.GetInstanceTypes(ctx, map[string]string{
+		"cloud":               cloud,
+		"region":              region,
+		"shade_instance_type": shadeInstanceType,
+	})
+	if err != nil {
+		tflog.Warn(ctx, fmt.Sprintf("could not look up the boot time of %s in %s %s: %s", shadeInstanceType, cloud, region, err))
+		return nil
+	}
+
+	for _, t := range instanceTypes {
+		if t.Cloud == cloud && t.ShadeInstanceType == shadeInstanceType && t.BootTime != nil {
+			return t.BootTime
+		}
+	}
+	return nil
+}
+
+// bootProgress describes how long an instance has been booting compared to
+// the boot time of its type.
+func bootProgress(elapsed time.Duration, bootTime *provider_shadeform.BootTime) string {
+	elapsed = elapsed.Round(time.Second)
+	if bootTime == nil || bootTime.MaxBootInSec == 0 {
+		return fmt.Sprintf("elapsed %s", elapsed)
+	}
+	return fmt.Sprintf("elapsed %s, expected boot time %s-%s",
+		elapsed,
+		time.Duration(bootTime.MinBootInSec)*time.Second,
+		time.Duration(bootTime.MaxBootInSec)*time.Second,
+	)
+}
@@ -0,0 +1,73 @@
+package instance
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+)
+
+// startupScriptHash returns the hex encoded SHA-256 of script, which is what
+// we keep in state instead of the script itself.
+func startupScriptHash(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// scriptLaunchConfiguration wraps script in the launch configuration the API
+// expects.
+func scriptLaunchConfiguration(script string) *provider_shadeform.LaunchConfiguration {
+	return &provider_shadeform.LaunchConfiguration{
+		Type: provider_shadeform.LaunchConfigurationTypeScript,
+		ScriptConfiguration: &provider_shadeform.ScriptConfiguration{
+			Base64Script: base64.StdEncoding.EncodeToString([]byte(script)),
+		},
+	}
+}
+
+var _ planmodifier.String = startupScriptHashModifier{}
+
+// startupScriptHashModifier plans startup_script_sha256 from the write-only
+// startup_script in the configuration, and replaces the instance when the
+// hash changes since the script only runs at boot.
+type startupScriptHashModifier struct{}
+
+func (m startupScriptHashModifier) Description(_ context.Context) string {
+	return "Sets the value to the SHA-256 of startup_script and requires replacement when it changes."
+}
+
+func (m startupScriptHashModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m startupScriptHashModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	// Nothing to plan when the resource is being destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var script types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("startup_script"), &script)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	switch {
+	case script.IsUnknown():
+		resp.PlanValue = types.StringUnknown()
+	case script.IsNull():
+		resp.PlanValue = types.StringNull()
+	default:
+		resp.PlanValue = types.StringValue(startupScriptHash(script.ValueString()))
+	}
+
+	if !req.State.Raw.IsNull() && !resp.PlanValue.Equal(req.StateValue) {
+		resp.RequiresReplace = true
+	}
+}
@@ -0,0 +1,165 @@
+package instance
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+
+	"github.com/shadeform/terraform-provider-shadeform/internal/convert"
+	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+)
+
+type ThresholdsModel struct {
+	DateThreshold  types.String  `tfsdk:"date_threshold"`
+	SpendThreshold types.Float64 `tfsdk:"spend_threshold"`
+}
+
+var thresholdsAttrTypes = map[string]attr.Type{
+	"date_threshold":  types.StringType,
+	"spend_threshold": types.Float64Type,
+}
+
+// thresholdsBlock is the schema shared by the auto_delete and alert blocks.
+func thresholdsBlock(description, action string) schema.Block {
+	return schema.SingleNestedBlock{
+		Description: description,
+		Attributes: map[string]schema.Attribute{
+			"date_threshold": schema.StringAttribute{
+				Description: "The date and time, in RFC3339 format, at which the instance is " + action + ".",
+				Optional:    true,
+			},
+			"spend_threshold": schema.Float64Attribute{
+				Description: "The amount spent on the instance, in USD, at which the instance is " + action + ".",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+// validateThresholds checks the thresholds block at name in config.
+func validateThresholds(ctx context.Context, config tfsdk.Config, name string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var obj types.Object
+	diags.Append(config.GetAttribute(ctx, path.Root(name), &obj)...)
+	if diags.HasError() || obj.IsNull() || obj.IsUnknown() {
+		return diags
+	}
+
+	var thresholds ThresholdsModel
+	diags.Append(obj.As(ctx, &thresholds, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return diags
+	}
+
+	if !thresholds.DateThreshold.IsNull() && !thresholds.DateThreshold.IsUnknown() {
+		if _, err := time.Parse(time.RFC3339, thresholds.DateThreshold.ValueString()); err != nil {
+			diags.AddAttributeError(
+				path.Root(name).AtName("date_threshold"),
+				"Invalid Date Threshold",
+				"date_threshold must be an RFC3339 timestamp such as \"2025-01-31T18:00:00Z\": "+err.Error(),
+			)
+		}
+	}
+
+	if !thresholds.SpendThreshold.IsNull() && !thresholds.SpendThreshold.IsUnknown() && thresholds.SpendThreshold.ValueFloat64() <= 0 {
+		diags.AddAttributeError(
+			path.Root(name).AtName("spend_threshold"),
+			"Invalid Spend Threshold",
+			"spend_threshold must be a positive amount in USD.",
+		)
+	}
+
+	return diags
+}
+
+// expandThresholds converts a thresholds block into its API representation.
+// It returns nil when the block is not set.
+func expandThresholds(ctx context.Context, obj types.Object) (*provider_shadeform.Thresholds, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if obj.IsNull() || obj.IsUnknown() {
+		return nil, diags
+	}
+
+	var thresholds ThresholdsModel
+	diags.Append(obj.As(ctx, &thresholds, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	result := &provider_shadeform.Thresholds{
+		DateThreshold: thresholds.DateThreshold.ValueString(),
+	}
+	if !thresholds.SpendThreshold.IsNull() && !thresholds.SpendThreshold.IsUnknown() {
+		result.SpendThreshold = provider_shadeform.FlexString(strconv.FormatFloat(thresholds.SpendThreshold.ValueFloat64(), 'f', -1, 64))
+	}
+
+	return result, diags
+}
+
+// flattenThresholds converts thresholds reported by the API into a block. The
+// configured date is kept when the API reports the same instant in another
+// format. Instances the API reports no thresholds for keep prior as is.
+func flattenThresholds(ctx context.Context, api *provider_shadeform.Thresholds, prior types.Object) (types.Object, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if api == nil || (api.DateThreshold == "" && api.SpendThreshold == "") {
+		if prior.IsUnknown() {
+			return types.ObjectNull(thresholdsAttrTypes), diags
+		}
+		return prior, diags
+	}
+
+	var priorThresholds ThresholdsModel
+	if !prior.IsNull() && !prior.IsUnknown() {
+		diags.Append(prior.As(ctx, &priorThresholds, basetypes.ObjectAsOptions{})...)
+		if diags.HasError() {
+			return prior, diags
+		}
+	}
+
+	thresholds := ThresholdsModel{
+		DateThreshold:  convert.StringOrNull(api.DateThreshold),
+		SpendThreshold: types.Float64Null(),
+	}
+	if sameInstant(api.DateThreshold, priorThresholds.DateThreshold.ValueString()) {
+		thresholds.DateThreshold = priorThresholds.DateThreshold
+	}
+	if api.SpendThreshold != "" {
+		spend, err := strconv.ParseFloat(string(api.SpendThreshold), 64)
+		if err != nil {
+			diags.AddError(
+				"Error reading instance thresholds",
+				"Could not parse spend_threshold "+strconv.Quote(string(api.SpendThreshold))+": "+err.Error(),
+			)
+			return prior, diags
+		}
+		thresholds.SpendThreshold = types.Float64Value(spend)
+	}
+
+	result, d := types.ObjectValueFrom(ctx, thresholdsAttrTypes, thresholds)
+	diags.Append(d...)
+	return result, diags
+}
+
+// sameInstant reports whether two RFC3339 timestamps denote the same time.
+func sameInstant(a, b string) bool {
+	ta, err := time.Parse(time.RFC3339, a)
+	if err != nil {
+		return false
+	}
+	tb, err := time.Parse(time.RFC3339, b)
+	if err != nil {
+		return false
+	}
+	return ta.Equal(tb)
+}
@@ -0,0 +1,153 @@
+package instance
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+)
+
+// ttl is converted into an absolute expires_at once, when the instance is
+// created, and sent to the API as the auto_delete date threshold. Changing ttl
+// later moves expires_at by the difference, so the deadline stays anchored to
+// the original creation rather than to the time of the apply.
+
+// validateTTL checks that ttl is a positive duration and that it does not
+// compete with auto_delete.date_threshold for the same deadline.
+func validateTTL(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var ttl types.String
+	diags.Append(config.GetAttribute(ctx, path.Root("ttl"), &ttl)...)
+	if diags.HasError() || ttl.IsNull() || ttl.IsUnknown() {
+		return diags
+	}
+
+	d, err := time.ParseDuration(ttl.ValueString())
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("ttl"),
+			"Invalid TTL",
+			"ttl must be a duration such as \"72h\" or \"90m\": "+err.Error(),
+		)
+		return diags
+	}
+	if d <= 0 {
+		diags.AddAttributeError(
+			path.Root("ttl"),
+			"Invalid TTL",
+			"ttl must be a positive duration.",
+		)
+		return diags
+	}
+
+	var dateThreshold types.String
+	diags.Append(config.GetAttribute(ctx, path.Root("auto_delete").AtName("date_threshold"), &dateThreshold)...)
+	if diags.HasError() {
+		return diags
+	}
+	if !dateThreshold.IsNull() {
+		diags.AddAttributeError(
+			path.Root("ttl"),
+			"Conflicting Auto Delete Date",
+			"ttl and auto_delete.date_threshold both set when the instance is deleted, only one of them can be used.",
+		)
+	}
+
+	return diags
+}
+
+// planExpiresAt plans expires_at from the change to ttl. It is unknown when a
+// ttl is added, since the deadline is only fixed when it is applied.
+func planExpiresAt(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var planTTL types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("ttl"), &planTTL)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var stateTTL, stateExpiresAt types.String
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("ttl"), &stateTTL)...)
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("expires_at"), &stateExpiresAt)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	expiresAt := types.StringUnknown()
+	switch {
+	case planTTL.IsNull():
+		expiresAt = types.StringNull()
+	case planTTL.IsUnknown():
+		// Stays unknown until the ttl is known
+	case planTTL.Equal(stateTTL) && !stateExpiresAt.IsNull():
+		expiresAt = stateExpiresAt
+	default:
+		if moved, ok := moveExpiresAt(stateExpiresAt, stateTTL, planTTL); ok {
+			expiresAt = types.StringValue(moved)
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("expires_at"), expiresAt)...)
+}
+
+// moveExpiresAt shifts expiresAt by the difference between two ttl values. It
+// reports false when there is no earlier deadline to move.
+func moveExpiresAt(expiresAt, oldTTL, newTTL types.String) (string, bool) {
+	if expiresAt.IsNull() || expiresAt.IsUnknown() || oldTTL.IsNull() || oldTTL.IsUnknown() {
+		return "", false
+	}
+
+	t, err := time.Parse(time.RFC3339, expiresAt.ValueString())
+	if err != nil {
+		return "", false
+	}
+	oldD, err := time.ParseDuration(oldTTL.ValueString())
+	if err != nil {
+		return "", false
+	}
+	newD, err := time.ParseDuration(newTTL.ValueString())
+	if err != nil {
+		return "", false
+	}
+
+	return t.Add(newD - oldD).UTC().Format(time.RFC3339), true
+}
+
+// resolveExpiresAt fixes an unknown expires_at as ttl from now.
+func (m *InstanceResourceModel) resolveExpiresAt() {
+	if !m.ExpiresAt.IsUnknown() {
+		return
+	}
+	if m.TTL.IsNull() || m.TTL.IsUnknown() {
+		m.ExpiresAt = types.StringNull()
+		return
+	}
+
+	// ValidateConfig has already checked the duration
+	d, _ := time.ParseDuration(m.TTL.ValueString())
+	m.ExpiresAt = types.StringValue(time.Now().Add(d).UTC().Format(time.RFC3339))
+}
+
+// autoDeleteThresholds returns the auto_delete thresholds sent to the API,
+// with expires_at as the date threshold when a ttl is set.
+func (m *InstanceResourceModel) autoDeleteThresholds(ctx context.Context) (*provider_shadeform.Thresholds, diag.Diagnostics) {
+	thresholds, diags := expandThresholds(ctx, m.AutoDelete)
+	if diags.HasError() || m.ExpiresAt.IsNull() || m.ExpiresAt.IsUnknown() {
+		return thresholds, diags
+	}
+
+	if thresholds == nil {
+		thresholds = &provider_shadeform.Thresholds{}
+	}
+	thresholds.DateThreshold = m.ExpiresAt.ValueString()
+
+	return thresholds, diags
+}
@@ -0,0 +1,115 @@
+package tags
+
+import (
+	"context"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+)
+
+// The API only knows a single list of tags per object, so the provider
+// default_tags and the tags configured on a resource are merged before they
+// are sent and split again when they are read back.
+
+// Merge returns the union of the configured tags and the provider default
+// tags, which is planned as tags_all and sent to the API.
+func Merge(ctx context.Context, tags types.Set, defaultTags []string) (types.Set, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if tags.IsUnknown() {
+		return types.SetUnknown(types.StringType), diags
+	}
+	if tags.IsNull() && len(defaultTags) == 0 {
+		return types.SetNull(types.StringType), diags
+	}
+
+	var merged []string
+	if !tags.IsNull() {
+		diags.Append(tags.ElementsAs(ctx, &merged, false)...)
+		if diags.HasError() {
+			return types.SetUnknown(types.StringType), diags
+		}
+	}
+	for _, t := range defaultTags {
+		if !slices.Contains(merged, t) {
+			merged = append(merged, t)
+		}
+	}
+
+	return setValue(merged), diags
+}
+
+// PlanAll plans tags_all as the union of tags and the provider default tags
+// of client. client is nil while the provider configuration is still unknown,
+// which leaves tags_all unknown. A change to the default tags is applied in
+// place like any other tag change.
+func PlanAll(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, client *provider_shadeform.Client) {
+	tagsAll := types.SetUnknown(types.StringType)
+
+	var planTags types.Set
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("tags"), &planTags)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if client != nil {
+		var diags diag.Diagnostics
+		tagsAll, diags = Merge(ctx, planTags, client.DefaultTags())
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("tags_all"), tagsAll)...)
+}
+
+// Flatten splits the tags reported by the API into tags and tags_all.
+// tags_all is everything the API reports. tags leaves out the provider default
+// tags, unless they were also configured on the resource, so that defaults do
+// not show up as drift.
+func Flatten(apiTags []string, priorTags, priorTagsAll types.Set, defaultTags []string) (types.Set, types.Set) {
+	configured := map[string]bool{}
+	if !priorTags.IsNull() && !priorTags.IsUnknown() {
+		for _, v := range priorTags.Elements() {
+			if s, ok := v.(types.String); ok {
+				configured[s.ValueString()] = true
+			}
+		}
+	}
+
+	var own []string
+	for _, t := range apiTags {
+		if configured[t] || !slices.Contains(defaultTags, t) {
+			own = append(own, t)
+		}
+	}
+
+	return flatten(own, priorTags), flatten(apiTags, priorTagsAll)
+}
+
+// flatten converts tags into a set. An empty set in prior is kept so that
+// configuring tags = [] does not show a diff.
+func flatten(tags []string, prior types.Set) types.Set {
+	if len(tags) == 0 {
+		if !prior.IsNull() && !prior.IsUnknown() && len(prior.Elements()) == 0 {
+			return prior
+		}
+		return types.SetNull(types.StringType)
+	}
+	return setValue(tags)
+}
+
+func setValue(tags []string) types.Set {
+	values := make([]attr.Value, 0, len(tags))
+	for _, t := range tags {
+		values = append(values, types.StringValue(t))
+	}
+	return types.SetValueMust(types.StringType, values)
+}
@@ -3,19 +3,33 @@ package volume
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/shadeform/terraform-provider-shadeform/internal/convert"
 	"github.com/shadeform/terraform-provider-shadeform/internal/provider/provider_shadeform"
+	"github.com/shadeform/terraform-provider-shadeform/internal/resources/tags"
 )
 
+// statusLookupTimeout bounds the API call describing a mounting instance after
+// the delete deadline has passed.
+const statusLookupTimeout = 30 * time.Second
+
 var (
 	_ resource.Resource                = &VolumeResource{}
 	_ resource.ResourceWithConfigure   = &VolumeResource{}
 	_ resource.ResourceWithImportState = &VolumeResource{}
+	_ resource.ResourceWithModifyPlan  = &VolumeResource{}
 )
 
 type VolumeResource struct {
@@ -23,15 +37,19 @@ type VolumeResource struct {
 }
 
 type VolumeResourceModel struct {
-	Id                 types.String `tfsdk:"id"`
-	Cloud              types.String `tfsdk:"cloud"`
-	Region             types.String `tfsdk:"region"`
-	Name               types.String `tfsdk:"name"`
-	SizeInGb           types.Int64  `tfsdk:"size_in_gb"`
-	FixedSize          types.Bool   `tfsdk:"fixed_size"`
-	SupportsMultiMount types.Bool   `tfsdk:"supports_multi_mount"`
-	CostEstimate       types.String `tfsdk:"cost_estimate"`
-	MountedBy          types.String `tfsdk:"mounted_by"`
+	Id                 types.String   `tfsdk:"id"`
+	Cloud              types.String   `tfsdk:"cloud"`
+	Region             types.String   `tfsdk:"region"`
+	Name               types.String   `tfsdk:"name"`
+	SizeInGb           types.Int64    `tfsdk:"size_in_gb"`
+	FixedSize          types.Bool     `tfsdk:"fixed_size"`
+	SupportsMultiMount types.Bool     `tfsdk:"supports_multi_mount"`
+	CostEstimate       types.String   `tfsdk:"cost_estimate"`
+	MountedBy          types.String   `tfsdk:"mounted_by"`
+	Tags               types.Set      `tfsdk:"tags"`
+	TagsAll            types.Set      `tfsdk:"tags_all"`
+	WaitForUnmount     types.Bool     `tfsdk:"wait_for_unmount"`
+	Timeouts           timeouts.Value `tfsdk:"timeouts"`
 }
 
 func NewVolumeResource() resource.Resource {
@@ -44,37 +62,58 @@ func (r *VolumeResource) Metadata(_ context.Context, req resource.MetadataReques
 }
 
 // Schema defines the schema for the resource.
-func (r *VolumeResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *VolumeResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages a Shadeform storage volume.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				Description: "The unique identifier for the volume.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"cloud": schema.StringAttribute{
-				Description: "The cloud provider.",
+				Description: "The cloud provider. Changing it replaces the volume.",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"region": schema.StringAttribute{
-				Description: "The region where the volume will be created.",
+				Description: "The region where the volume will be created. Changing it replaces the volume.",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"name": schema.StringAttribute{
-				Description: "The name of the volume.",
+				Description: "The name of the volume. Changing it replaces the volume.",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"size_in_gb": schema.Int64Attribute{
-				Description: "The size of the volume in gigabytes.",
+				Description: "The size of the volume in gigabytes. Changing it replaces the volume.",
 				Required:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
 			},
 			"fixed_size": schema.BoolAttribute{
 				Description: "Whether the volume is fixed in size or elastically scaling.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"supports_multi_mount": schema.BoolAttribute{
 				Description: "Whether the volume supports multiple instances mounting to it.",
 				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"cost_estimate": schema.StringAttribute{
 				Description: "The cost estimate for the volume.",
@@ -84,10 +123,39 @@ func (r *VolumeResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 				Description: "The ID of the instance that is currently mounting the volume.",
 				Computed:    true,
 			},
+			"tags": schema.SetAttribute{
+				ElementType: types.StringType,
+				Description: "Tags attached to the volume. Can be changed without replacing the volume.",
+				Optional:    true,
+			},
+			"tags_all": schema.SetAttribute{
+				ElementType: types.StringType,
+				Description: "All tags attached to the volume, including the provider default_tags.",
+				Computed:    true,
+			},
+			"wait_for_unmount": schema.BoolAttribute{
+				Description: "Whether deleting the volume waits, within the delete timeout, for the instance mounting it to let go of it, for example while that instance is destroyed in the same run. If false, deleting a mounted volume fails right away. Defaults to true.",
+				Optional:    true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Delete: true,
+			}),
 		},
 	}
 }
 
+// ModifyPlan plans tags_all as the union of tags and the provider default_tags.
+func (r *VolumeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan when the resource is being destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	tags.PlanAll(ctx, req, resp, r.client)
+}
+
 // Configure adds the provider configured client to the resource.
 func (r *VolumeResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
@@ -119,15 +187,29 @@ func (r *VolumeResource) Create(ctx context.Context, req resource.CreateRequest,
 	}
 
 	// Build request body
-	requestBody := map[string]interface{}{
-		"cloud":      plan.Cloud.ValueString(),
-		"region":     plan.Region.ValueString(),
-		"name":       plan.Name.ValueString(),
-		"size_in_gb": plan.SizeInGb.ValueInt64(),
+	requestBody := &provider_shadeform.CreateVolumeRequest{
+		Cloud:    plan.Cloud.ValueString(),
+		Region:   plan.Region.ValueString(),
+		Name:     plan.Name.ValueString(),
+		SizeInGB: plan.SizeInGb.ValueInt64(),
+	}
+
+	// Add tags, including the provider default tags, if any
+	tagsAll, diags := tags.Merge(ctx, plan.Tags, r.client.DefaultTags())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !tagsAll.IsNull() {
+		diags = tagsAll.ElementsAs(ctx, &requestBody.Tags, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
 	// Create volume
-	result, err := r.client.CreateVolume(requestBody)
+	result, err := r.client.CreateVolume(ctx, requestBody)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error creating volume",
@@ -136,9 +218,8 @@ func (r *VolumeResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	// Extract volume ID from response
-	volumeID, ok := result["id"].(string)
-	if !ok {
+	volumeID := result.ID
+	if volumeID == "" {
 		resp.Diagnostics.AddError(
 			"Error creating volume",
 			"Could not extract volume ID from response",
@@ -147,7 +228,7 @@ func (r *VolumeResource) Create(ctx context.Context, req resource.CreateRequest,
 	}
 
 	// Now fetch the full volume info to populate all computed fields
-	volumeInfo, err := r.client.GetVolume(volumeID)
+	volumeInfo, err := r.client.GetVolume(ctx, volumeID)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error reading volume after create",
@@ -158,38 +239,7 @@ func (r *VolumeResource) Create(ctx context.Context, req resource.CreateRequest,
 
 	// Set all fields from the API response
 	plan.Id = types.StringValue(volumeID)
-	if cloud, ok := volumeInfo["cloud"].(string); ok {
-		plan.Cloud = types.StringValue(cloud)
-	}
-	if region, ok := volumeInfo["region"].(string); ok {
-		plan.Region = types.StringValue(region)
-	}
-	if name, ok := volumeInfo["name"].(string); ok {
-		plan.Name = types.StringValue(name)
-	}
-	if sizeInGb, ok := volumeInfo["size_in_gb"].(float64); ok {
-		plan.SizeInGb = types.Int64Value(int64(sizeInGb))
-	}
-	if fixedSize, ok := volumeInfo["fixed_size"].(bool); ok {
-		plan.FixedSize = types.BoolValue(fixedSize)
-	}
-	if supportsMultiMount, ok := volumeInfo["supports_multi_mount"].(bool); ok {
-		plan.SupportsMultiMount = types.BoolValue(supportsMultiMount)
-	}
-	if costEstimate, ok := volumeInfo["cost_estimate"].(string); ok {
-		plan.CostEstimate = types.StringValue(costEstimate)
-	}
-
-	// Handle mounted_by - it can be null when not mounted
-	if mountedBy, ok := volumeInfo["mounted_by"]; ok && mountedBy != nil {
-		if mountedByStr, ok := mountedBy.(string); ok {
-			plan.MountedBy = types.StringValue(mountedByStr)
-		} else {
-			plan.MountedBy = types.StringNull()
-		}
-	} else {
-		plan.MountedBy = types.StringNull()
-	}
+	plan.flatten(volumeInfo, r.client.DefaultTags())
 
 	// Set state
 	diags = resp.State.Set(ctx, plan)
@@ -207,7 +257,13 @@ func (r *VolumeResource) Read(ctx context.Context, req resource.ReadRequest, res
 	}
 
 	// Get volume from API
-	result, err := r.client.GetVolume(state.Id.ValueString())
+	result, err := r.client.GetVolume(ctx, state.Id.ValueString())
+	if provider_shadeform.IsNotFound(err) {
+		// The volume is gone, so let the next plan recreate it
+		tflog.Warn(ctx, fmt.Sprintf("volume %s no longer exists, removing from state", state.Id.ValueString()))
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error reading volume",
@@ -217,38 +273,7 @@ func (r *VolumeResource) Read(ctx context.Context, req resource.ReadRequest, res
 	}
 
 	// Update state with API response
-	if cloud, ok := result["cloud"].(string); ok {
-		state.Cloud = types.StringValue(cloud)
-	}
-	if region, ok := result["region"].(string); ok {
-		state.Region = types.StringValue(region)
-	}
-	if name, ok := result["name"].(string); ok {
-		state.Name = types.StringValue(name)
-	}
-	if sizeInGb, ok := result["size_in_gb"].(float64); ok {
-		state.SizeInGb = types.Int64Value(int64(sizeInGb))
-	}
-	if fixedSize, ok := result["fixed_size"].(bool); ok {
-		state.FixedSize = types.BoolValue(fixedSize)
-	}
-	if supportsMultiMount, ok := result["supports_multi_mount"].(bool); ok {
-		state.SupportsMultiMount = types.BoolValue(supportsMultiMount)
-	}
-	if costEstimate, ok := result["cost_estimate"].(string); ok {
-		state.CostEstimate = types.StringValue(costEstimate)
-	}
-
-	// Handle mounted_by - it can be null when not mounted
-	if mountedBy, ok := result["mounted_by"]; ok && mountedBy != nil {
-		if mountedByStr, ok := mountedBy.(string); ok {
-			state.MountedBy = types.StringValue(mountedByStr)
-		} else {
-			state.MountedBy = types.StringNull()
-		}
-	} else {
-		state.MountedBy = types.StringNull()
-	}
+	state.flatten(result, r.client.DefaultTags())
 
 	// Set state
 	diags = resp.State.Set(ctx, &state)
@@ -257,15 +282,54 @@ func (r *VolumeResource) Read(ctx context.Context, req resource.ReadRequest, res
 
 // Update updates the resource and sets the updated Terraform state on success.
 func (r *VolumeResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	// Volumes don't support updates in the API, so we'll just return the current state
+	// Get plan and state
 	var plan VolumeResourceModel
+	var state VolumeResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Set state (no changes since volumes can't be updated)
+	// Tags are the only field the API can change in place
+	if !plan.TagsAll.Equal(state.TagsAll) {
+		// An empty list clears the tags
+		tagsAll := []string{}
+		if !plan.TagsAll.IsNull() {
+			diags = plan.TagsAll.ElementsAs(ctx, &tagsAll, false)
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+
+		err := r.client.UpdateVolume(ctx, state.Id.ValueString(), &provider_shadeform.UpdateVolumeRequest{Tags: &tagsAll})
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error updating volume",
+				"Could not update volume, unexpected error: "+err.Error(),
+			)
+			return
+		}
+	}
+
+	// Fetch the updated volume data to ensure all computed fields are set
+	volumeInfo, err := r.client.GetVolume(ctx, state.Id.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading volume after update",
+			"Could not read volume, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	// Update the plan with the fetched data
+	plan.Id = types.StringValue(state.Id.ValueString())
+	plan.flatten(volumeInfo, r.client.DefaultTags())
+
+	// Set state
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 }
@@ -280,8 +344,22 @@ func (r *VolumeResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
+	const defaultDeleteTimeout = 10 * time.Minute
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
 	// Check if volume is mounted before attempting to delete
-	volumeInfo, err := r.client.GetVolume(state.Id.ValueString())
+	volumeInfo, err := r.client.GetVolume(ctx, state.Id.ValueString())
+	if provider_shadeform.IsNotFound(err) {
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error reading volume before delete",
@@ -291,18 +369,33 @@ func (r *VolumeResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	}
 
 	// Check if volume is mounted
-	if mountedBy, ok := volumeInfo["mounted_by"]; ok && mountedBy != nil {
-		if mountedByStr, ok := mountedBy.(string); ok && mountedByStr != "" {
+	if volumeInfo.MountedBy != "" {
+		if !state.WaitForUnmount.IsNull() && !state.WaitForUnmount.ValueBool() {
 			resp.Diagnostics.AddError(
 				"Error deleting volume",
-				fmt.Sprintf("Cannot delete volume %s because it is mounted by instance %s. Please delete the instance first.", state.Id.ValueString(), mountedByStr),
+				fmt.Sprintf("Cannot delete volume %s because it is mounted by instance %s. Please delete the instance first.", state.Id.ValueString(), volumeInfo.MountedBy),
+			)
+			return
+		}
+
+		volumeInfo, err = waitForVolumeUnmounted(ctx, r.client, state.Id.ValueString(), volumeInfo, 5*time.Second)
+		if provider_shadeform.IsNotFound(err) {
+			return
+		}
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error deleting volume",
+				fmt.Sprintf("Volume %s is still mounted by instance %s: %s", state.Id.ValueString(), volumeInfo.MountedBy, err),
 			)
 			return
 		}
 	}
 
 	// Delete volume
-	err = r.client.DeleteVolume(state.Id.ValueString())
+	err = r.client.DeleteVolume(ctx, state.Id.ValueString())
+	if provider_shadeform.IsNotFound(err) {
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error deleting volume",
@@ -310,6 +403,14 @@ func (r *VolumeResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		)
 		return
 	}
+
+	if err := waitForVolumeDeleted(ctx, r.client, state.Id.ValueString(), 5*time.Second); err != nil {
+		resp.Diagnostics.AddError(
+			"Error waiting for volume deletion",
+			fmt.Sprintf("Volume %s was not deleted: %s", state.Id.ValueString(), err),
+		)
+		return
+	}
 }
 
 // ImportState imports the resource into Terraform state.
@@ -317,3 +418,111 @@ func (r *VolumeResource) ImportState(ctx context.Context, req resource.ImportSta
 	// Import by volume ID
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
+
+// waitForVolumeUnmounted blocks until the mounted volume is no longer mounted
+// or the ctx deadline is hit. The last volume seen is returned either way, and
+// an expired wait reports the status of the instance still mounting it.
+func waitForVolumeUnmounted(
+	ctx context.Context,
+	c *provider_shadeform.Client,
+	id string,
+	volume *provider_shadeform.Volume,
+	interval time.Duration,
+) (*provider_shadeform.Volume, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return volume, fmt.Errorf("%w, instance status %s", ctx.Err(), mountingInstanceStatus(ctx, c, volume.MountedBy))
+		case <-ticker.C:
+			result, err := c.GetVolume(ctx, id)
+			if err != nil && ctx.Err() != nil {
+				// The deadline passed while the request was in flight
+				return volume, fmt.Errorf("%w, instance status %s", ctx.Err(), mountingInstanceStatus(ctx, c, volume.MountedBy))
+			}
+			if err != nil {
+				return volume, err
+			}
+			volume = result
+			if volume.MountedBy == "" {
+				return volume, nil
+			}
+			tflog.Debug(ctx, fmt.Sprintf("waiting for volume %s to be unmounted by instance %s", id, volume.MountedBy))
+		}
+	}
+}
+
+// mountingInstanceStatus looks up the status of the instance mounting a
+// volume for error messages. ctx may already be done.
+func mountingInstanceStatus(ctx context.Context, c *provider_shadeform.Client, instanceID string) string {
+	if instanceID == "" {
+		return "unknown"
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), statusLookupTimeout)
+	defer cancel()
+
+	instance, err := c.GetInstance(ctx, instanceID)
+	if provider_shadeform.IsNotFound(err) {
+		return provider_shadeform.InstanceStatusDeleted
+	}
+	if err != nil || instance.Status == "" {
+		return "unknown"
+	}
+	return instance.Status
+}
+
+// waitForVolumeDeleted blocks until the volume is no longer found or the ctx
+// deadline is hit.
+func waitForVolumeDeleted(
+	ctx context.Context,
+	c *provider_shadeform.Client,
+	id string,
+	interval time.Duration,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err() // timeout or user ^C
+		case <-ticker.C:
+			_, err := c.GetVolume(ctx, id)
+			if provider_shadeform.IsNotFound(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			tflog.Debug(ctx, fmt.Sprintf("waiting for volume %s to be deleted", id))
+		}
+	}
+}
+
+// flatten copies the fields of an API volume into the model.
+func (m *VolumeResourceModel) flatten(volume *provider_shadeform.Volume, defaultTags []string) {
+	// Required fields keep their configured value if the API omits them
+	if volume.Cloud != "" {
+		m.Cloud = types.StringValue(volume.Cloud)
+	}
+	if volume.Region != "" {
+		m.Region = types.StringValue(volume.Region)
+	}
+	if volume.Name != "" {
+		m.Name = types.StringValue(volume.Name)
+	}
+	if volume.SizeInGB != 0 {
+		m.SizeInGb = types.Int64Value(volume.SizeInGB)
+	}
+	m.FixedSize = types.BoolValue(volume.FixedSize)
+	m.SupportsMultiMount = types.BoolValue(volume.SupportsMultiMount)
+	m.CostEstimate = convert.StringOrNull(string(volume.CostEstimate))
+
+	// mounted_by is null when the volume is not mounted
+	m.MountedBy = convert.StringOrNull(volume.MountedBy)
+
+	m.Tags, m.TagsAll = tags.Flatten(volume.Tags, m.Tags, m.TagsAll, defaultTags)
+}